@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewAgentCmd() *cobra.Command {
+	var agentCmd = &cobra.Command{
+		Use:   "agent <command> [flags]",
+		Short: "Manage the vault unlock agent",
+		Long: heredoc.Doc(`
+			Manage the vault unlock agent.
+
+			The agent is a background process that keeps the encrypted credential
+			vault unlocked in memory, so commands don't ask for the passphrase every
+			time. It only answers requests from your own user and exits after an idle
+			timeout.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing agent start
+			$ dwing agent status
+			$ dwing agent stop
+		`),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	agentCmd.AddCommand(NewAgentStartCommand())
+	agentCmd.AddCommand(NewAgentStopCommand())
+	agentCmd.AddCommand(NewAgentStatusCommand())
+	agentCmd.AddCommand(NewAgentServeCommand())
+
+	return agentCmd
+}
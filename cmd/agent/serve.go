@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/agent"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewAgentServeCommand() *cobra.Command {
+	var idleTimeout time.Duration
+
+	var serveCmd = &cobra.Command{
+		Use:    "serve",
+		Short:  "Run the agent in the foreground",
+		Long:   `Run the agent in the foreground, reading the vault passphrase from stdin. Used by 'dwing agent start'.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && passphrase == "" {
+				return fmt.Errorf("failed to read passphrase: %w", err)
+			}
+			passphrase = strings.TrimRight(passphrase, "\r\n")
+			if passphrase == "" {
+				return errors.New("passphrase cannot be empty")
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+
+			repo := auth.NewEncryptedRepository(cfg.CredentialsPath, passphrase)
+			if err := repo.Unlock(); err != nil {
+				return fmt.Errorf("failed to unlock vault: %w", err)
+			}
+
+			socketPath, err := agent.DefaultSocketPath()
+			if err != nil {
+				return err
+			}
+
+			server := agent.NewServer(repo, cfg.CredentialsPath, socketPath, idleTimeout)
+			listener, err := server.Listen()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return server.Serve(ctx, listener)
+		},
+	}
+
+	serveCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", 15*time.Minute, "Lock the vault after this much inactivity")
+
+	return serveCmd
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/agent"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+const startupTimeout = 5 * time.Second
+
+func NewAgentStartCommand() *cobra.Command {
+	var idleTimeout time.Duration
+
+	var startCmd = &cobra.Command{
+		Use:   "start [flags]",
+		Short: "Unlock the vault and start the agent",
+		Long:  `Ask for the vault passphrase once and start a background agent that keeps the vault unlocked.`,
+		Example: heredoc.Doc(`
+			$ dwing agent start
+			$ dwing agent start --idle-timeout 1h
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			socketPath, err := agent.DefaultSocketPath()
+			if err != nil {
+				return err
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+
+			client := agent.NewClient(socketPath)
+			if status, err := client.Status(); err == nil {
+				if status.Serves(cfg.CredentialsPath) {
+					fmt.Println("Agent is already running")
+					return nil
+				}
+				return fmt.Errorf("agent is already running for %s, stop it with 'dwing agent stop' first", status.VaultPath)
+			}
+
+			encrypted, err := auth.IsVault(cfg.CredentialsPath)
+			if err != nil {
+				return err
+			}
+			if !encrypted {
+				return errors.New("credential store is not encrypted, run 'dwing creds encrypt' first")
+			}
+
+			passphrase, err := cmdutil.ReadPassphrase("Vault passphrase")
+			if err != nil {
+				return fmt.Errorf("failed to get passphrase: %w", err)
+			}
+
+			if err := auth.NewEncryptedRepository(cfg.CredentialsPath, passphrase).Unlock(); err != nil {
+				return fmt.Errorf("failed to unlock vault: %w", err)
+			}
+
+			configPath, err := cmdutil.ConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := spawnAgent(configPath, passphrase, idleTimeout); err != nil {
+				return err
+			}
+
+			if err := waitForAgent(client); err != nil {
+				return err
+			}
+
+			fmt.Printf("Agent started (idle timeout %s)\n", idleTimeout)
+
+			return nil
+		},
+	}
+
+	startCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", 15*time.Minute, "Lock the vault after this much inactivity")
+
+	return startCmd
+}
+
+// spawnAgent re-executes dwing as a detached 'agent serve' process and hands
+// it the passphrase over stdin, so it never shows up in the process list.
+func spawnAgent(configPath, passphrase string, idleTimeout time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find dwing executable: %w", err)
+	}
+
+	child := exec.Command(executable, "agent", "serve", "--config", configPath, "--idle-timeout", idleTimeout.String())
+	child.SysProcAttr = cmdutil.DetachAttr()
+
+	stdin, err := child.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create agent stdin: %w", err)
+	}
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(stdin, passphrase); err != nil {
+		return fmt.Errorf("failed to send passphrase to agent: %w", err)
+	}
+	stdin.Close()
+
+	return child.Process.Release()
+}
+
+func waitForAgent(client *agent.Client) error {
+	deadline := time.Now().Add(startupTimeout)
+	for time.Now().Before(deadline) {
+		if client.Ping() == nil {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return errors.New("agent did not start in time")
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/agent"
+	"jpellissari/dwing/internal/cmdutil"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewAgentStatusCommand() *cobra.Command {
+	var statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the agent is running",
+		Long:  `Show whether the agent is running and when it will lock the vault.`,
+		Example: heredoc.Doc(`
+			$ dwing agent status
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			socketPath, err := agent.DefaultSocketPath()
+			if err != nil {
+				return err
+			}
+
+			status, err := agent.NewClient(socketPath).Status()
+			if err != nil {
+				if errors.Is(err, agent.ErrNotRunning) {
+					return printer.Failure("Agent is not running")
+				}
+				return fmt.Errorf("failed to get agent status: %w", err)
+			}
+
+			return printer.Print(status, func(w io.Writer) error {
+				fmt.Fprintf(w, "Agent is running (pid %d)\n", status.PID)
+				fmt.Fprintf(w, "Vault:    %s\n", status.VaultPath)
+				fmt.Fprintf(w, "Started:  %s\n", status.StartedAt.Format(time.RFC1123))
+				fmt.Fprintf(w, "Locks in: %s\n", time.Until(status.ExpiresAt).Round(time.Second))
+				return nil
+			})
+		},
+	}
+
+	return statusCmd
+}
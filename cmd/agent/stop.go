@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/agent"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewAgentStopCommand() *cobra.Command {
+	var stopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the agent and lock the vault",
+		Long:  `Stop the running agent, dropping the unlocked vault from memory.`,
+		Example: heredoc.Doc(`
+			$ dwing agent stop
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			socketPath, err := agent.DefaultSocketPath()
+			if err != nil {
+				return err
+			}
+
+			if err := agent.NewClient(socketPath).Stop(); err != nil {
+				if errors.Is(err, agent.ErrNotRunning) {
+					fmt.Println("Agent is not running")
+					return nil
+				}
+				return fmt.Errorf("failed to stop agent: %w", err)
+			}
+
+			fmt.Println("Agent stopped")
+
+			return nil
+		},
+	}
+
+	return stopCmd
+}
@@ -0,0 +1,62 @@
+package completion
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/cmdutil"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewCompletionCmd() *cobra.Command {
+	var completionCmd = &cobra.Command{
+		Use:   "completion <bash|zsh|fish|powershell>",
+		Short: "Generate the shell completion script",
+		Long: heredoc.Doc(`
+			Generate the completion script for your shell.
+
+			Credential arguments complete to IDs described by their environment,
+			username and nickname. Completion only reads credential metadata: it
+			works with a plaintext store or a running agent and never prompts for
+			the vault passphrase.
+		`),
+		Example: heredoc.Doc(`
+			# bash, for the current session
+			$ source <(dwing completion bash)
+
+			# bash, for every session (Linux)
+			$ dwing completion bash > /etc/bash_completion.d/dwing
+
+			# zsh
+			$ dwing completion zsh > "${fpath[1]}/_dwing"
+
+			# fish
+			$ dwing completion fish > ~/.config/fish/completions/dwing.fish
+		`),
+		Annotations: map[string]string{
+			cmdutil.SkipConfigAnnotation: "true",
+		},
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			out := cmd.OutOrStdout()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+
+			return fmt.Errorf("unsupported shell %q", args[0])
+		},
+	}
+
+	return completionCmd
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+
+	"jpellissari/dwing/internal/config"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigCmd() *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config <command> [flags]",
+		Short: "Manage dwing configuration",
+		Long: heredoc.Docf(`
+			Manage dwing configuration.
+
+			Settings are stored in $XDG_CONFIG_HOME/dwing/config.yaml, or in the file
+			given with --config. Every key can be overridden with a DWING_<KEY>
+			environment variable, e.g. DWING_BACKEND=agent.
+
+			Available keys:
+			%s
+		`, keysHelp()),
+		Example: heredoc.Doc(`
+			$ dwing config list
+			$ dwing config get backend
+			$ dwing config set default_environment dev
+		`),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	configCmd.AddCommand(NewConfigGetCommand())
+	configCmd.AddCommand(NewConfigSetCommand())
+	configCmd.AddCommand(NewConfigListCommand())
+
+	return configCmd
+}
+
+func keysHelp() string {
+	var b strings.Builder
+	for _, key := range config.Keys {
+		b.WriteString("  " + key.Name + ": " + key.Description + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func completeKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(config.Keys))
+	for _, key := range config.Keys {
+		names = append(names, key.Name+"\t"+key.Description)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/cmdutil"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigGetCommand() *cobra.Command {
+	var getCmd = &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a config key",
+		Long:  `Print the effective value of a config key, after applying the config file and environment overrides.`,
+		Example: heredoc.Doc(`
+			$ dwing config get credentials_path
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+
+			value, err := cfg.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			return printer.Print(map[string]string{args[0]: value}, func(w io.Writer) error {
+				_, err := fmt.Fprintln(w, value)
+				return err
+			})
+		},
+	}
+
+	return getCmd
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"io"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/config"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+type setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func NewConfigListCommand() *cobra.Command {
+	var listCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List every config key",
+		Long:    `List the effective value of every config key and where it comes from: default, file or env.`,
+		Aliases: []string{"ls"},
+		Example: heredoc.Doc(`
+			$ dwing config list
+			$ dwing config ls -o yaml
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+
+			path, err := cmdutil.ConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+
+			file, err := config.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			settings := make([]setting, 0, len(config.Keys))
+			for _, key := range config.Keys {
+				value, _ := cfg.Get(key.Name)
+				fileValue, _ := file.Get(key.Name)
+
+				source := "default"
+				if os.Getenv(key.EnvVar()) != "" {
+					source = "env"
+				} else if fileValue != "" {
+					source = "file"
+				}
+
+				settings = append(settings, setting{Key: key.Name, Value: value, Source: source})
+			}
+
+			return printer.Print(settings, func(w io.Writer) error {
+				data := [][]string{}
+				for _, s := range settings {
+					data = append(data, []string{s.Key, s.Value, s.Source})
+				}
+
+				table := tablewriter.NewTable(w)
+				table.Header([]string{"Key", "Value", "Source"})
+				table.Bulk(data)
+				return table.Render()
+			})
+		},
+	}
+
+	return listCmd
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/config"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigSetCommand() *cobra.Command {
+	var setCmd = &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a config key",
+		Long:  `Validate and store a value in the config file. Setting an empty value resets the key to its default.`,
+		Example: heredoc.Doc(`
+			$ dwing config set backend encrypted
+			$ dwing config set credentials_path ~/vaults/work.json
+			$ dwing config set default_environment ""
+		`),
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeKeys,
+		Annotations: map[string]string{
+			cmdutil.SkipConfigAnnotation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			path, err := cmdutil.ConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.Set(key, value); err != nil {
+				return err
+			}
+
+			if err := cfg.WriteFile(path); err != nil {
+				return err
+			}
+
+			return printer.Success(fmt.Sprintf("Set %s in %s", key, path), map[string]string{key: value})
+		},
+	}
+
+	return setCmd
+}
@@ -4,98 +4,236 @@ import (
 	"errors"
 	"fmt"
 	"jpellissari/dwing/internal/auth"
-	"jpellissari/dwing/internal/config"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/otp"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func NewCredsAddCommand() *cobra.Command {
 	var cred = auth.Credential{}
+	var fields, fieldFiles []string
+	var generate bool
 
 	var addCmd = &cobra.Command{
 		Use:   "add [flags]",
 		Short: "Add a new credential",
-		Long:  `Add a new credential to your credential store, either interactively or by specifying details via flags.`,
+		Long: heredoc.Docf(`
+			Add a new credential to your credential store, either interactively or by specifying details via flags.
+
+			Credentials have a type, password by default, that defines their fields.
+			Every type has a username identifying the credential and most keep their
+			main secret in the password field, e.g. the token of a bearer_token
+			credential. Other fields are set with --field or --field-file.
+
+			Available types: %s
+		`, strings.Join(auth.TypeNames(), ", ")),
 		Example: heredoc.Doc(`
 			$ dwing creds add (interactive)
-			$ dwing creds add -u myuser -p mypass -e dev -n mynick
+			$ dwing creds add -u myuser -e dev -n mynick --password-stdin < password.txt
+			$ dwing creds add -u myuser -e dev --password-cmd "pass show dev/myuser"
+			$ dwing creds add -u myuser -e dev --password-fd 3 3< password.txt
+			$ dwing creds add -u myuser -e prod --generate --length 32 --copy
+			$ dwing creds add -t database -u app -e dev --field host=db.dev --field port=5432 --password-stdin < password.txt
+			$ dwing creds add -t ssh_key -u deploy -e prod --field-file private_key=$HOME/.ssh/id_ed25519
 		`),
 		Annotations: map[string]string{
 			"help:arguments": heredoc.Doc(`
 				A credential can be added interactively using the 'dwing creds add' command.
 				Or can be specified directly using flags:
 				-u, --username <username>        Specify the username for the credential
-				-p, --password <password>        Specify the password for the credential
+				    --password-stdin             Read the password from standard input
+				    --password-file <path>       Read the password from a file
+				    --password-cmd <command>     Read the password from the output of a command
+				    --password-fd <fd>           Read the password from an open file descriptor
+				-p, --password <password>        Specify the password directly (visible in process listings)
+				    --generate                   Generate the password, following the environment's policy
 				-e, --env <environment>          Specify the environment (e.g., dev, staging, prod)
 				-n, --nickname <nickname>        Specify a nickname for easy reference (optional)
+				    --otp-seed <seed>            TOTP seed, base32 or otpauth:// URI (optional)
+				-t, --type <type>                Credential type (default password)
+				    --field <name>=<value>       Set a field of the credential type
+				    --field-file <name>=<path>   Set a field of the credential type from a file
 			`),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			flagMode := cred.Username != "" || cred.Password != "" || cred.Environment != "" || cred.Nickname != ""
+			flagMode := cred.Username != "" || cred.Environment != "" || cred.Nickname != "" || cred.OTPSeed != "" ||
+				len(fields) > 0 || len(fieldFiles) > 0 || generate || cmdutil.PasswordFlagsChanged(cmd)
+
+			if err := cmdutil.CheckGeneratorFlags(cmd, "generate"); err != nil {
+				return err
+			}
+
+			if _, err := auth.LookupType(cred.Type); err != nil {
+				return err
+			}
+
+			password, ok, err := cmdutil.ReadPassword(cmd)
+			if err != nil {
+				return err
+			}
+			if ok {
+				cred.Password = password
+			}
+
+			values, err := parseFields(fields, fieldFiles)
+			if err != nil {
+				return err
+			}
+			for name, value := range values {
+				cred.Set(name, value)
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+			if cred.Environment == "" {
+				cred.Environment = cfg.DefaultEnvironment
+			}
+
+			if generate {
+				cred.Password, err = cmdutil.GenerateSecret(cmd, cred.Environment)
+				if err != nil {
+					return err
+				}
+			}
+
+			envService, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
 			if !flagMode {
-				err := promptForCredential(&cred)
+				if !cmd.Flags().Changed("type") {
+					if err := promptForType(&cred); err != nil {
+						return fmt.Errorf("failed to get credential input: %w", err)
+					}
+				}
+				err := promptForCredential(&cred, environmentNames(envService))
 				if err != nil {
 					return fmt.Errorf("failed to get credential input: %w", err)
 				}
-
-				return addCredential(cred)
+			} else if err := validateFlags(&cred); err != nil {
+				return err
 			}
 
-			if err := validateFlags(&cred); err != nil {
+			cred.Environment, err = envService.Canonicalize(cred.Environment)
+			if err != nil {
 				return err
 			}
 
-			return addCredential(cred)
+			return addCredential(cmd, cred)
 		},
 	}
 
-	addCmd.Flags().StringVarP(&cred.Environment, "environment", "e", "", "Environment (required)")
+	addCmd.Flags().StringVarP(&cred.Environment, "environment", "e", "", "Environment (required unless default_environment is configured)")
 	addCmd.Flags().StringVarP(&cred.Username, "username", "u", "", "Username (required)")
-	addCmd.Flags().StringVarP(&cred.Password, "password", "p", "", "Password (required)")
+	addCmd.Flags().StringVarP(&cred.Password, "password", "p", "", "Password (prefer --password-stdin, --password-file, --password-cmd or --password-fd)")
 	addCmd.Flags().StringVarP(&cred.Nickname, "nickname", "n", "", "Nickname (optional)")
+	addCmd.Flags().StringVar(&cred.OTPSeed, "otp-seed", "", "TOTP seed, base32 or otpauth:// URI (optional)")
+	addCmd.Flags().StringVarP(&cred.Type, "type", "t", "", "Credential type (default password)")
+	addCmd.Flags().BoolVar(&generate, "generate", false, "Generate the password, following the environment's password policy")
+	addFieldFlags(addCmd, &fields, &fieldFiles)
+	cmdutil.AddPasswordFlags(addCmd)
+	cmdutil.AddGeneratorFlags(addCmd)
+	addCmd.MarkFlagsMutuallyExclusive("generate", cmdutil.PasswordFlag, cmdutil.PasswordStdinFlag, cmdutil.PasswordFileFlag, cmdutil.PasswordCmdFlag, cmdutil.PasswordFDFlag)
+
+	// Accept --env like 'creds list' does.
+	addCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "env" {
+			name = "environment"
+		}
+		return pflag.NormalizedName(name)
+	})
+
+	_ = addCmd.RegisterFlagCompletionFunc("environment", cmdutil.CompleteEnvironments)
+	_ = addCmd.RegisterFlagCompletionFunc("type", cobra.FixedCompletions(auth.TypeNames(), cobra.ShellCompDirectiveNoFileComp))
 
 	return addCmd
 }
 
+// validateFlags checks that the environment and the required fields of the
+// credential's type were given.
 func validateFlags(c *auth.Credential) error {
-	allRequiredFlagsSet := c.Username != "" && c.Password != "" && c.Environment != ""
+	t, err := auth.LookupType(c.Type)
+	if err != nil {
+		return err
+	}
 
-	if !allRequiredFlagsSet {
-		return fmt.Errorf("when using flags, --username, --password, and --environment are required")
+	var missing []string
+	for _, f := range t.Fields {
+		if !f.Required || c.Get(f.Name) != "" {
+			continue
+		}
+		switch f.Name {
+		case auth.FieldUsername:
+			missing = append(missing, "--username")
+		case auth.FieldPassword:
+			missing = append(missing, "a password")
+		default:
+			missing = append(missing, fmt.Sprintf("--field %s=<value>", f.Name))
+		}
+	}
+	if c.Environment == "" {
+		missing = append(missing, "--environment")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("when using flags, %s credentials require %s", t.Name, strings.Join(missing, ", "))
 	}
 
 	return nil
 }
 
-func promptForCredential(c *auth.Credential) error {
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Title("Environment").
-				Prompt(">").
-				Value(&c.Environment).
-				Validate(requiredFieldValidator),
-			huh.NewInput().
-				Title("Username").
-				Prompt(">").
-				Value(&c.Username).
-				Validate(requiredFieldValidator),
-			huh.NewInput().
-				Title("Password").
-				Prompt(">").
-				Value(&c.Password).
-				EchoMode(huh.EchoModePassword).
-				Validate(requiredFieldValidator),
-			huh.NewInput().
-				Title("Nickname").
-				Prompt(">").
-				Value(&c.Nickname),
-		),
-	)
-
-	return form.Run()
+// promptForCredential asks for the environment, the fields of the
+// credential's type and a nickname.
+func promptForCredential(c *auth.Credential, environments []string) error {
+	fields, apply, err := schemaFields(c)
+	if err != nil {
+		return err
+	}
+
+	group := []huh.Field{
+		huh.NewInput().
+			Title("Environment").
+			Prompt(">").
+			Value(&c.Environment).
+			Suggestions(environments).
+			Validate(requiredFieldValidator),
+	}
+	group = append(group, fields...)
+	group = append(group, huh.NewInput().
+		Title("Nickname").
+		Prompt(">").
+		Value(&c.Nickname))
+
+	if err := huh.NewForm(huh.NewGroup(group...)).Run(); err != nil {
+		return err
+	}
+
+	apply()
+	return nil
+}
+
+// environmentNames lists known environment names and aliases to suggest in
+// the interactive form.
+func environmentNames(service *environment.Service) []string {
+	envs, err := service.ListEnvironments()
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, env := range envs {
+		names = append(names, env.Names()...)
+	}
+	return names
 }
 
 func requiredFieldValidator(s string) error {
@@ -105,21 +243,42 @@ func requiredFieldValidator(s string) error {
 	return nil
 }
 
-func addCredential(c auth.Credential) error {
-	cfg, err := config.NewDefaultConfig()
+func otpSeedValidator(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := otp.Parse(s)
+	return err
+}
+
+func addCredential(cmd *cobra.Command, c auth.Credential) error {
+	printer, err := cmdutil.NewPrinter(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
-	repo := auth.NewJSONRepository(cfg.CredentialsPath)
-	service := auth.NewCredentialService(repo)
+	service, err := cmdutil.NewCredentialService(cmd)
+	if err != nil {
+		return err
+	}
 
-	err = service.AddCredential(c)
+	added, err := service.AddCredential(c)
 	if err != nil {
 		return fmt.Errorf("failed to add credential: %w", err)
 	}
 
-	fmt.Printf("Credential added successfully: (%s) - %s\n", c.Environment, c.Username)
+	message := fmt.Sprintf("Credential added successfully: (%s) - %s", added.Environment, added.Username)
+	if toClipboard, _ := cmd.Flags().GetBool(cmdutil.CopyFlag); toClipboard {
+		copied, err := cmdutil.CopySecret(cmd, added.Password)
+		if err != nil {
+			return err
+		}
+		message += ", generated password " + copied
+	}
 
-	return nil
+	if !printer.ShowSecrets {
+		added = added.Redacted()
+	}
+
+	return printer.Success(message, added)
 }
@@ -0,0 +1,66 @@
+package creds
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/cmdutil"
+	"os"
+
+	"filippo.io/age"
+)
+
+// bundleRecipients parses the age public keys of --recipient. Without any,
+// the bundle is encrypted with a passphrase asked for twice.
+func bundleRecipients(keys []string) ([]age.Recipient, error) {
+	if len(keys) == 0 {
+		passphrase, err := cmdutil.ReadBundlePassphrase(true)
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --recipient %q: %w", key, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// bundleIdentities reads the age identity files of --identity. Without any,
+// the bundle is opened with a passphrase.
+func bundleIdentities(paths []string) ([]age.Identity, error) {
+	if len(paths) == 0 {
+		passphrase, err := cmdutil.ReadBundlePassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	var identities []age.Identity
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open identity file: %w", err)
+		}
+		parsed, err := age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity file %s: %w", path, err)
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
@@ -0,0 +1,169 @@
+package creds
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/clipboard"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/runner"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewCredsCopyCommand() *cobra.Command {
+	var field string
+
+	var copyCmd = &cobra.Command{
+		Use:     "copy <credential_id> [flags]",
+		Short:   "Copy a secret of a credential to the clipboard",
+		Aliases: []string{"cp"},
+		Long: heredoc.Doc(`
+			Copy a field of a credential to the clipboard, by default its main secret:
+			the password, or e.g. the private key of an ssh_key credential. Use
+			--field otp to copy the current TOTP code.
+
+			The clipboard is cleared after clipboard_timeout (45s by default) if it
+			still holds the secret. Set clipboard_timeout to 0 to keep it.
+
+			When there is no local clipboard, e.g. over SSH or in a headless session,
+			the secret is sent to the terminal with an OSC 52 escape sequence, which
+			most terminal emulators and tmux copy to the clipboard of the machine they
+			run on. Terminals do not let dwing read that clipboard back, so it is
+			cleared after the timeout even if something else was copied since.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing creds copy dev/alice
+			$ dwing creds copy db --field username
+			$ dwing creds copy dev/alice --field otp
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			cred, err := service.ResolveWithSecrets(id)
+			if err != nil {
+				if errors.Is(err, auth.ErrCredentialNotFound) {
+					return fmt.Errorf("credential '%s' not found", id)
+				}
+				return fmt.Errorf("failed to get credential: %w", err)
+			}
+
+			name, value, err := copiedField(cred, field)
+			if err != nil {
+				return err
+			}
+
+			copied, err := cmdutil.CopySecret(cmd, value)
+			if err != nil {
+				return err
+			}
+
+			return printer.Success(fmt.Sprintf("Field %s of (%s) - %s %s", name, cred.Environment, cred.Username, copied), nil)
+		},
+	}
+
+	copyCmd.Flags().StringVarP(&field, "field", "f", "", "Field to copy, e.g. username or otp (default the credential's main secret)")
+
+	_ = copyCmd.RegisterFlagCompletionFunc("field", completeCopyFields)
+
+	return copyCmd
+}
+
+// copiedField returns the name and value of the field to copy, defaulting to
+// the main secret of the credential's type.
+func copiedField(cred auth.Credential, field string) (string, string, error) {
+	if field == "" {
+		t, err := auth.LookupType(cred.Type)
+		if err != nil {
+			return "", "", err
+		}
+		field = t.PrimarySecret()
+	}
+	field = strings.ToLower(field)
+
+	value, err := runner.FieldValue(cred, field)
+	if err != nil {
+		return "", "", err
+	}
+	if value == "" {
+		return "", "", fmt.Errorf("field %s of credential %s is empty", field, cred.ID)
+	}
+
+	return field, value, nil
+}
+
+func completeCopyFields(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := append([]string{}, runner.Fields...)
+	for _, t := range auth.Types {
+		for _, f := range t.Fields {
+			if !slices.Contains(names, f.Name) {
+				names = append(names, f.Name)
+			}
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// NewCredsClearClipboardCommand is started in the background by commands
+// copying a secret. It reads the digest of the secret from stdin, waits, and
+// clears the clipboard.
+func NewCredsClearClipboardCommand() *cobra.Command {
+	var after time.Duration
+	var method string
+
+	var clearCmd = &cobra.Command{
+		Use:    "clear-clipboard",
+		Short:  "Clear a copied secret from the clipboard",
+		Long:   `Wait, then clear the clipboard if it still holds the secret whose SHA-256 digest is read from stdin. Used by 'dwing creds copy'.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		Annotations: map[string]string{
+			cmdutil.SkipConfigAnnotation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("failed to read digest: %w", err)
+			}
+			sum, err := hex.DecodeString(strings.TrimSpace(line))
+			if err != nil {
+				return fmt.Errorf("invalid digest: %w", err)
+			}
+
+			time.Sleep(after)
+
+			switch clipboard.Method(method) {
+			case clipboard.MethodSystem:
+				_, err := clipboard.ClearIfUnchanged(sum)
+				return err
+			case clipboard.MethodOSC52:
+				return clipboard.ClearOSC52(cmd.OutOrStdout())
+			default:
+				return fmt.Errorf("unknown clipboard method %q", method)
+			}
+		},
+	}
+
+	clearCmd.Flags().DurationVar(&after, "after", 0, "Time to wait before clearing")
+	clearCmd.Flags().StringVar(&method, "method", string(clipboard.MethodSystem), "How the secret was copied: system or osc52")
+
+	return clearCmd
+}
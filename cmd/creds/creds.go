@@ -9,12 +9,25 @@ func NewCredsCmd() *cobra.Command {
 	var credsCmd = &cobra.Command{
 		Use:   "creds <command> [flags]",
 		Short: "Manage your credentials",
-		Long:  `Manage your credentials and generate tokens as needed for various environments.`,
+		Long: heredoc.Doc(`
+			Manage your credentials and generate tokens as needed for various environments.
+
+			Commands that take a <credential-id> also accept a nickname, an
+			<environment>/<username> or <environment>/<nickname> pair or a unique
+			prefix of the ID (at least 4 characters).
+		`),
 		Example: heredoc.Doc(`
 			$ dwing creds ls
+			$ dwing creds show <credential-id>
 			$ dwing creds add
 			$ dwing creds rm <credential-id>
+			$ dwing creds edit <credential-id>
+			$ dwing creds edit dev/alice
 			$ dwing creds login <credential-id>
+			$ dwing creds otp <credential-id>
+			$ dwing creds copy <credential-id>
+			$ dwing creds import --format bitwarden <file>
+			$ dwing creds export --file <file>
 		`),
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd.Help()
@@ -36,9 +49,42 @@ func NewCredsCmd() *cobra.Command {
 	credsRemoveCmd := NewCredsRemoveCommand()
 	credsRemoveCmd.GroupID = credsGroup.ID
 
+	credsEditCmd := NewCredsEditCommand()
+	credsEditCmd.GroupID = credsGroup.ID
+
+	credsLoginCmd := NewCredsLoginCommand()
+	credsLoginCmd.GroupID = credsGroup.ID
+
+	credsOTPCmd := NewCredsOTPCommand()
+	credsOTPCmd.GroupID = credsGroup.ID
+
+	credsShowCmd := NewCredsShowCommand()
+	credsShowCmd.GroupID = credsGroup.ID
+
+	credsCopyCmd := NewCredsCopyCommand()
+	credsCopyCmd.GroupID = credsGroup.ID
+
+	credsImportCmd := NewCredsImportCommand()
+	credsImportCmd.GroupID = credsGroup.ID
+
+	credsExportCmd := NewCredsExportCommand()
+	credsExportCmd.GroupID = credsGroup.ID
+
+	credsEncryptCmd := NewCredsEncryptCommand()
+	credsEncryptCmd.GroupID = credsGroup.ID
+
 	credsCmd.AddCommand(credsAddCmd)
 	credsCmd.AddCommand(credsListCmd)
 	credsCmd.AddCommand(credsRemoveCmd)
+	credsCmd.AddCommand(credsEditCmd)
+	credsCmd.AddCommand(credsLoginCmd)
+	credsCmd.AddCommand(credsOTPCmd)
+	credsCmd.AddCommand(credsShowCmd)
+	credsCmd.AddCommand(credsCopyCmd)
+	credsCmd.AddCommand(credsImportCmd)
+	credsCmd.AddCommand(credsExportCmd)
+	credsCmd.AddCommand(credsEncryptCmd)
+	credsCmd.AddCommand(NewCredsClearClipboardCommand())
 
 	return credsCmd
 }
@@ -0,0 +1,174 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewCredsEditCommand() *cobra.Command {
+	var changes = auth.Credential{}
+	var fields, fieldFiles []string
+	var rotate bool
+
+	var editCmd = &cobra.Command{
+		Use:     "edit <credential_id> [flags]",
+		Short:   "Edit a stored credential",
+		Long:    `Edit a stored credential, either interactively with its current values prefilled or by specifying only the fields to change via flags. The credential keeps its ID.`,
+		Aliases: []string{"update"},
+		Example: heredoc.Doc(`
+			$ dwing creds edit <credential_id> (interactive)
+			$ dwing creds edit <credential_id> --password-stdin < password.txt
+			$ dwing creds edit <credential_id> -n mynick -e staging
+			$ dwing creds edit <credential_id> --otp-seed 'otpauth://totp/ACME:alice?secret=...'
+			$ dwing creds edit <credential_id> --field host=db.staging --field port=
+			$ dwing creds edit <credential_id> --rotate --copy
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			if err := cmdutil.CheckGeneratorFlags(cmd, "rotate"); err != nil {
+				return err
+			}
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			cred, err := service.ResolveWithSecrets(id)
+			if err != nil {
+				if errors.Is(err, auth.ErrCredentialNotFound) {
+					return fmt.Errorf("credential '%s' not found", id)
+				}
+				return fmt.Errorf("failed to get credential: %w", err)
+			}
+
+			envService, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			password, passwordSet, err := cmdutil.ReadPassword(cmd)
+			if err != nil {
+				return err
+			}
+			if rotate {
+				env := cred.Environment
+				if cmd.Flags().Changed("environment") {
+					env = changes.Environment
+				}
+				password, err = cmdutil.GenerateSecret(cmd, env)
+				if err != nil {
+					return err
+				}
+				passwordSet = true
+			}
+			changes.Password = password
+
+			changes.Fields, err = parseFields(fields, fieldFiles)
+			if err != nil {
+				return err
+			}
+
+			flagMode := applyChanges(&cred, changes, func(name string) bool {
+				switch name {
+				case cmdutil.PasswordFlag:
+					return passwordSet
+				case fieldFlag:
+					return len(changes.Fields) > 0
+				}
+				return cmd.Flags().Changed(name)
+			})
+			if !flagMode {
+				if err := promptForCredential(&cred, environmentNames(envService)); err != nil {
+					return fmt.Errorf("failed to get credential input: %w", err)
+				}
+			}
+
+			cred.Environment, err = envService.Canonicalize(cred.Environment)
+			if err != nil {
+				return err
+			}
+
+			if err := service.UpdateCredential(cred); err != nil {
+				return fmt.Errorf("failed to update credential: %w", err)
+			}
+
+			message := fmt.Sprintf("Credential updated successfully: (%s) - %s", cred.Environment, cred.Username)
+			if toClipboard, _ := cmd.Flags().GetBool(cmdutil.CopyFlag); toClipboard {
+				copied, err := cmdutil.CopySecret(cmd, cred.Password)
+				if err != nil {
+					return err
+				}
+				message += ", new password " + copied
+			}
+			if !printer.ShowSecrets {
+				cred = cred.Redacted()
+			}
+
+			return printer.Success(message, cred)
+		},
+	}
+
+	editCmd.Flags().StringVarP(&changes.Environment, "environment", "e", "", "New environment")
+	editCmd.Flags().StringVarP(&changes.Username, "username", "u", "", "New username")
+	editCmd.Flags().StringVarP(&changes.Password, "password", "p", "", "New password (prefer --password-stdin, --password-file, --password-cmd or --password-fd)")
+	editCmd.Flags().StringVarP(&changes.Nickname, "nickname", "n", "", "New nickname")
+	editCmd.Flags().StringVar(&changes.OTPSeed, "otp-seed", "", "New TOTP seed, empty to remove it")
+	editCmd.Flags().BoolVar(&rotate, "rotate", false, "Replace the password with a generated one, following the environment's password policy")
+	addFieldFlags(editCmd, &fields, &fieldFiles)
+	cmdutil.AddPasswordFlags(editCmd)
+	cmdutil.AddGeneratorFlags(editCmd)
+	editCmd.MarkFlagsMutuallyExclusive("rotate", cmdutil.PasswordFlag, cmdutil.PasswordStdinFlag, cmdutil.PasswordFileFlag, cmdutil.PasswordCmdFlag, cmdutil.PasswordFDFlag)
+
+	_ = editCmd.RegisterFlagCompletionFunc("environment", cmdutil.CompleteEnvironments)
+
+	return editCmd
+}
+
+// applyChanges copies the fields whose flags were set from changes into c and
+// reports whether any flag was set at all.
+func applyChanges(c *auth.Credential, changes auth.Credential, changed func(name string) bool) bool {
+	applied := false
+
+	if changed("environment") {
+		c.Environment = changes.Environment
+		applied = true
+	}
+	if changed("username") {
+		c.Username = changes.Username
+		applied = true
+	}
+	if changed("password") {
+		c.Password = changes.Password
+		applied = true
+	}
+	if changed("nickname") {
+		c.Nickname = changes.Nickname
+		applied = true
+	}
+	if changed("otp-seed") {
+		c.OTPSeed = changes.OTPSeed
+		applied = true
+	}
+	if changed(fieldFlag) {
+		for name, value := range changes.Fields {
+			c.Set(name, value)
+		}
+		applied = true
+	}
+
+	return applied
+}
@@ -0,0 +1,106 @@
+package creds
+
+import (
+	"testing"
+
+	"jpellissari/dwing/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyChanges(t *testing.T) {
+	current := auth.Credential{
+		ID:          "1",
+		Environment: "dev",
+		Username:    "user1",
+		Password:    "pass1",
+		Nickname:    "nick1",
+	}
+
+	tests := []struct {
+		name        string
+		changes     auth.Credential
+		changed     []string
+		want        auth.Credential
+		wantApplied bool
+	}{
+		{
+			name:        "No flags keeps credential untouched",
+			changes:     auth.Credential{Password: "ignored"},
+			want:        current,
+			wantApplied: false,
+		},
+		{
+			name:    "Only changed flags are applied",
+			changes: auth.Credential{Password: "rotated", Username: "ignored"},
+			changed: []string{"password"},
+			want: auth.Credential{
+				ID:          "1",
+				Environment: "dev",
+				Username:    "user1",
+				Password:    "rotated",
+				Nickname:    "nick1",
+			},
+			wantApplied: true,
+		},
+		{
+			name:    "OTP seed is added",
+			changes: auth.Credential{OTPSeed: "JBSWY3DPEHPK3PXP"},
+			changed: []string{"otp-seed"},
+			want: auth.Credential{
+				ID:          "1",
+				Environment: "dev",
+				Username:    "user1",
+				Password:    "pass1",
+				Nickname:    "nick1",
+				OTPSeed:     "JBSWY3DPEHPK3PXP",
+			},
+			wantApplied: true,
+		},
+		{
+			name:    "Fields are set and empty values remove them",
+			changes: auth.Credential{Fields: map[string]string{"host": "db.staging", "port": ""}},
+			changed: []string{"field"},
+			want: auth.Credential{
+				ID:          "1",
+				Environment: "dev",
+				Username:    "user1",
+				Password:    "pass1",
+				Nickname:    "nick1",
+				Fields:      map[string]string{"host": "db.staging"},
+			},
+			wantApplied: true,
+		},
+		{
+			name:    "Nickname can be cleared",
+			changes: auth.Credential{},
+			changed: []string{"nickname"},
+			want: auth.Credential{
+				ID:          "1",
+				Environment: "dev",
+				Username:    "user1",
+				Password:    "pass1",
+			},
+			wantApplied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred := current
+			changed := func(name string) bool {
+				for _, c := range tt.changed {
+					if c == name {
+						return true
+					}
+				}
+				return false
+			}
+
+			applied := applyChanges(&cred, tt.changes, changed)
+
+			assert.Equal(t, tt.wantApplied, applied)
+			assert.Equal(t, tt.want, cred)
+		})
+	}
+}
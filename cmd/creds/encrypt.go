@@ -0,0 +1,89 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewCredsEncryptCommand() *cobra.Command {
+	var encryptCmd = &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the credential store with a master passphrase",
+		Long: heredoc.Doc(`
+			Encrypt the credential store at rest with a master passphrase.
+
+			Existing plaintext credentials are migrated into the encrypted vault. Only
+			secrets are encrypted: listing credentials keeps working without the
+			passphrase, while commands that use a secret, such as login or run, ask for
+			it unless it is provided through the DWING_PASSPHRASE environment variable
+			or the agent is running.
+
+			Vaults created by older versions of dwing, which encrypted the whole file,
+			are upgraded to the current format.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing creds encrypt
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+
+			encrypted, err := auth.IsVault(cfg.CredentialsPath)
+			if err != nil {
+				return err
+			}
+			if encrypted {
+				repo := auth.NewEncryptedRepositoryFunc(cfg.CredentialsPath, func() (string, error) {
+					return cmdutil.ReadPassphrase("Vault passphrase")
+				})
+
+				upgraded, err := repo.Upgrade()
+				if err != nil {
+					return err
+				}
+				if !upgraded {
+					return errors.New("credential store is already encrypted")
+				}
+
+				return printer.Success("Vault upgraded to per-secret encryption", nil)
+			}
+
+			plain := auth.NewJSONRepository(cfg.CredentialsPath)
+			creds, err := plain.GetAll()
+			if err != nil {
+				return fmt.Errorf("failed to read credentials: %w", err)
+			}
+			revision, _ := plain.Revision()
+
+			passphrase, err := cmdutil.ReadNewPassphrase()
+			if err != nil {
+				return fmt.Errorf("failed to get passphrase: %w", err)
+			}
+
+			// Credentials added or changed while the passphrase was typed
+			// must not be lost, so the vault is only written when the store
+			// is still at the revision that was read.
+			repo := auth.NewEncryptedRepository(cfg.CredentialsPath, passphrase)
+			if err := repo.SaveAt(creds, revision); err != nil {
+				return fmt.Errorf("failed to encrypt credentials: %w", err)
+			}
+
+			return printer.Success(fmt.Sprintf("Credential store encrypted successfully (%d credentials)", len(creds)), nil)
+		},
+	}
+
+	return encryptCmd
+}
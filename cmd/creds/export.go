@@ -0,0 +1,205 @@
+package creds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/fsutil"
+	"jpellissari/dwing/internal/transfer"
+	"slices"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+const unsafePlaintextFlag = "unsafe-plaintext"
+
+func NewCredsExportCommand() *cobra.Command {
+	var format, env, file string
+	var recipients []string
+	var unsafePlaintext bool
+
+	var exportCmd = &cobra.Command{
+		Use:   "export [<credential_id>...] [flags]",
+		Short: "Export credentials for backups and migrations",
+		Long: heredoc.Docf(`
+			Export credentials with their secrets, all of them or only those given as
+			arguments or in the environment of --env.
+
+			The default format is an encrypted bundle, which 'dwing creds import
+			--format bundle' reads on another machine. It is encrypted with age for
+			the public keys given with --recipient, or else with a passphrase. Scripts
+			can set the passphrase in %[1]s.
+
+			The json, csv and dotenv formats write the secrets in plaintext and need
+			--%[2]s. They are read back by 'dwing creds import' too. Exporting
+			the secrets of a high danger environment in plaintext asks for
+			confirmation first, which --yes skips.
+
+			The export is written to standard output unless --file is given, in
+			which case the file is only readable by you.
+
+			Available formats: %[3]s
+		`, cmdutil.BundlePassphraseEnv, unsafePlaintextFlag, strings.Join(transfer.ExportFormats, ", ")),
+		Example: heredoc.Doc(`
+			$ dwing creds export --file dwing-backup.age
+			$ dwing creds export -e prod --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p > prod.age
+			$ dwing creds export dev/alice dev/bob --format dotenv --unsafe-plaintext > .env
+			$ dwing creds export --format csv --unsafe-plaintext --file creds.csv
+		`),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(transfer.ExportFormats, format) {
+				return fmt.Errorf("%w %q, expected one of: %s", transfer.ErrUnknownFormat, format, strings.Join(transfer.ExportFormats, ", "))
+			}
+			if format == transfer.FormatBundle && unsafePlaintext {
+				return fmt.Errorf("--%s cannot be used with encrypted bundles", unsafePlaintextFlag)
+			}
+			if format != transfer.FormatBundle {
+				if len(recipients) > 0 {
+					return errors.New("--recipient requires --format bundle")
+				}
+				if !unsafePlaintext {
+					return fmt.Errorf("--format %s writes secrets in plaintext, pass --%s to allow it or export an encrypted bundle", format, unsafePlaintextFlag)
+				}
+			}
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			envService, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			if env != "" {
+				env, err = envService.Canonicalize(env)
+				if err != nil {
+					return err
+				}
+			}
+
+			var ids []string
+			for _, ref := range args {
+				cred, err := service.Resolve(ref)
+				if err != nil {
+					if errors.Is(err, auth.ErrCredentialNotFound) {
+						return fmt.Errorf("credential '%s' not found", ref)
+					}
+					return fmt.Errorf("failed to get credential: %w", err)
+				}
+				ids = append(ids, cred.ID)
+			}
+
+			creds, err := service.ListCredentialsWithSecrets(env)
+			if err != nil {
+				return fmt.Errorf("failed to list credentials: %w", err)
+			}
+			if len(ids) > 0 {
+				creds = slices.DeleteFunc(creds, func(c auth.Credential) bool {
+					return !slices.Contains(ids, c.ID)
+				})
+			}
+			if len(creds) == 0 {
+				return errors.New("no credentials to export")
+			}
+			// Cached tokens are tied to this machine's sessions, not worth moving.
+			for i := range creds {
+				creds[i].Token = nil
+			}
+
+			if format != transfer.FormatBundle {
+				if err := confirmPlaintextExport(cmd, envService, creds); err != nil {
+					return err
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := writeExport(&buf, format, creds, recipients); err != nil {
+				return err
+			}
+
+			if file == "" {
+				_, err := cmd.OutOrStdout().Write(buf.Bytes())
+				return err
+			}
+
+			if err := fsutil.WriteFileAtomic(file, buf.Bytes(), 0600); err != nil {
+				return fmt.Errorf("failed to write export: %w", err)
+			}
+
+			return printer.Success(fmt.Sprintf("Exported %d credentials to %s", len(creds), file), nil)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&format, "format", "f", transfer.FormatBundle, "Format of the export: "+strings.Join(transfer.ExportFormats, ", "))
+	exportCmd.Flags().StringVarP(&env, "env", "e", "", "Only export the credentials of this environment")
+	exportCmd.Flags().StringVar(&file, "file", "", "Write the export to this file instead of standard output")
+	exportCmd.Flags().StringArrayVarP(&recipients, "recipient", "r", nil, "Encrypt the bundle for this age public key instead of a passphrase (repeatable)")
+	exportCmd.Flags().BoolVar(&unsafePlaintext, unsafePlaintextFlag, false, "Allow formats that write secrets in plaintext")
+	cmdutil.AddYesFlag(exportCmd)
+
+	_ = exportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(transfer.ExportFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = exportCmd.RegisterFlagCompletionFunc("env", cmdutil.CompleteEnvironments)
+
+	return exportCmd
+}
+
+// writeExport writes creds in format, encrypting bundles for the recipients.
+func writeExport(w io.Writer, format string, creds auth.Credentials, recipients []string) error {
+	if format != transfer.FormatBundle {
+		return transfer.Export(format, w, creds)
+	}
+
+	ageRecipients, err := bundleRecipients(recipients)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := transfer.Encrypt(w, ageRecipients...)
+	if err != nil {
+		return err
+	}
+	if err := transfer.Export(transfer.FormatJSON, bundle, creds); err != nil {
+		return err
+	}
+	return bundle.Close()
+}
+
+// confirmPlaintextExport asks before the secrets of a high danger environment
+// are written in plaintext.
+func confirmPlaintextExport(cmd *cobra.Command, envService *environment.Service, creds auth.Credentials) error {
+	var dangerous []string
+	for _, c := range creds {
+		if slices.Contains(dangerous, c.Environment) {
+			continue
+		}
+		env, err := envService.GetEnvironment(c.Environment)
+		if err != nil && !errors.Is(err, environment.ErrEnvironmentNotFound) {
+			return err
+		}
+		if env.IsDangerous() {
+			dangerous = append(dangerous, c.Environment)
+		}
+	}
+
+	if len(dangerous) == 0 {
+		return nil
+	}
+
+	return cmdutil.Confirm(cmd,
+		"Export secrets in plaintext?",
+		fmt.Sprintf("The export includes high danger environments: %s.", strings.Join(dangerous, ", ")))
+}
@@ -0,0 +1,87 @@
+package creds
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/transfer"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteExport(t *testing.T) {
+	creds := auth.Credentials{{ID: "1", Environment: "dev", Username: "alice", Password: "s3cret"}}
+
+	t.Run("Bundle for recipients", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, writeExport(&buf, transfer.FormatBundle, creds, []string{identity.Recipient().String()}))
+		assert.NotContains(t, buf.String(), "s3cret")
+
+		r, err := transfer.Decrypt(&buf, identity)
+		require.NoError(t, err)
+		entries, err := transfer.Parse(transfer.FormatJSON, r)
+		require.NoError(t, err)
+		assert.Equal(t, creds[0], entries[0].Credential)
+	})
+
+	t.Run("Invalid recipient", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeExport(&buf, transfer.FormatBundle, creds, []string{"ssh-ed25519 AAAA"})
+
+		assert.ErrorContains(t, err, "invalid --recipient")
+		assert.Zero(t, buf.Len())
+	})
+
+	t.Run("Plaintext", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeExport(&buf, transfer.FormatDotenv, creds, nil))
+
+		assert.Contains(t, buf.String(), "DEV_ALICE_PASSWORD='s3cret'\n")
+	})
+}
+
+func TestExportCommandFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	t.Setenv("DWING_BACKEND", "file")
+	t.Setenv("DWING_CREDENTIALS_PATH", filepath.Join(dir, "credentials.json"))
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "Unknown credential", args: []string{"missing"}, wantErr: "credential 'missing' not found"},
+		{name: "Nothing to export", wantErr: "no credentials to export"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewCredsExportCommand()
+			// Usage goes to the output writer once it is set, dwing prints
+			// it on standard error.
+			cmd.SilenceUsage = true
+			var stdout bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append(tt.args, "--recipient", identity.Recipient().String()))
+
+			err := cmd.Execute()
+
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package creds
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/secretinput"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+const (
+	fieldFlag     = "field"
+	fieldFileFlag = "field-file"
+)
+
+// addFieldFlags registers --field and --field-file, which set the fields of a
+// credential type schema.
+func addFieldFlags(cmd *cobra.Command, fields, fieldFiles *[]string) {
+	cmd.Flags().StringArrayVar(fields, fieldFlag, nil, "Set a field of the credential type as <name>=<value> (repeatable)")
+	cmd.Flags().StringArrayVar(fieldFiles, fieldFileFlag, nil, "Set a field of the credential type from a file as <name>=<path> (repeatable)")
+
+	_ = cmd.RegisterFlagCompletionFunc(fieldFlag, completeFieldNames)
+	_ = cmd.RegisterFlagCompletionFunc(fieldFileFlag, completeFieldNames)
+}
+
+// parseFields merges the values of --field and --field-file. Files are read
+// like --password-file, so a single trailing newline is dropped.
+func parseFields(fields, fieldFiles []string) (map[string]string, error) {
+	values := map[string]string{}
+
+	for _, f := range fields {
+		name, value, err := splitField(f, fieldFlag)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+
+	for _, f := range fieldFiles {
+		name, path, err := splitField(f, fieldFileFlag)
+		if err != nil {
+			return nil, err
+		}
+		value, err := secretinput.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read field %s: %w", name, err)
+		}
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+func splitField(value, flag string) (string, string, error) {
+	name, v, ok := strings.Cut(value, "=")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid --%s %q: must look like <name>=<value>", flag, value)
+	}
+	return name, v, nil
+}
+
+// completeFieldNames completes "<name>=" for the fields of the type given
+// with --type, or of every type when it is not set.
+func completeFieldNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	types := auth.Types
+	if f := cmd.Flags().Lookup("type"); f != nil && f.Value.String() != "" {
+		t, err := auth.LookupType(f.Value.String())
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		types = []auth.CredentialType{t}
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, t := range types {
+		for _, f := range t.Fields {
+			if seen[f.Name] || !strings.HasPrefix(f.Name, toComplete) {
+				continue
+			}
+			seen[f.Name] = true
+			names = append(names, f.Name+"=\t"+f.Label)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// promptForType asks for the type of a new credential.
+func promptForType(c *auth.Credential) error {
+	options := make([]huh.Option[string], 0, len(auth.Types))
+	for _, t := range auth.Types {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%s)", t.Description, t.Name), t.Name))
+	}
+
+	c.Type = c.Kind()
+	return huh.NewSelect[string]().
+		Title("Type").
+		Options(options...).
+		Value(&c.Type).
+		Run()
+}
+
+// schemaFields builds the form inputs for the fields of the credential's
+// type. The returned function copies the answers back into the credential.
+func schemaFields(c *auth.Credential) ([]huh.Field, func(), error) {
+	t, err := auth.LookupType(c.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make([]string, len(t.Fields))
+	fields := make([]huh.Field, 0, len(t.Fields))
+	for i, spec := range t.Fields {
+		values[i] = c.Get(spec.Name)
+
+		title := spec.Label
+		if !spec.Required {
+			title += " (optional)"
+		}
+		validate := func(string) error { return nil }
+		switch {
+		case spec.Name == auth.FieldOTPSeed:
+			validate = otpSeedValidator
+		case spec.Required:
+			validate = requiredFieldValidator
+		}
+
+		if spec.Multiline {
+			fields = append(fields, huh.NewText().
+				Title(title).
+				Description(spec.Description).
+				Value(&values[i]).
+				Validate(validate))
+			continue
+		}
+
+		input := huh.NewInput().
+			Title(title).
+			Description(spec.Description).
+			Prompt(">").
+			Value(&values[i]).
+			Validate(validate)
+		if spec.Secret {
+			input = input.EchoMode(huh.EchoModePassword)
+		}
+		fields = append(fields, input)
+	}
+
+	apply := func() {
+		for i, spec := range t.Fields {
+			c.Set(spec.Name, values[i])
+		}
+	}
+
+	return fields, apply, nil
+}
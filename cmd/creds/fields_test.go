@@ -0,0 +1,46 @@
+package creds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, []byte("-----BEGIN KEY-----\nabc\n-----END KEY-----\n"), 0600))
+
+	t.Run("values and files are merged", func(t *testing.T) {
+		got, err := parseFields([]string{"host=db.dev", "Port=5432", "database="}, []string{"private_key=" + keyPath})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"host":        "db.dev",
+			"port":        "5432",
+			"database":    "",
+			"private_key": "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		}, got)
+	})
+
+	t.Run("values may contain equal signs", func(t *testing.T) {
+		got, err := parseFields([]string{"token_url=https://idp/token?a=b"}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp/token?a=b", got["token_url"])
+	})
+
+	t.Run("malformed field", func(t *testing.T) {
+		_, err := parseFields([]string{"host"}, nil)
+
+		assert.ErrorContains(t, err, "<name>=<value>")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := parseFields(nil, []string{"private_key=" + filepath.Join(t.TempDir(), "missing")})
+
+		assert.ErrorContains(t, err, "failed to read field private_key")
+	})
+}
@@ -0,0 +1,261 @@
+package creds
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/transfer"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func NewCredsImportCommand() *cobra.Command {
+	var format, env, onDuplicate string
+	var identities []string
+	var dryRun bool
+
+	var importCmd = &cobra.Command{
+		Use:   "import --format <format> <file>",
+		Short: "Import credentials from another password manager",
+		Long: heredoc.Docf(`
+			Import the logins of a password manager export, or of a dotenv file. Use -
+			as the file to read standard input.
+
+			Formats:
+			  bundle      Encrypted bundle written by 'dwing creds export', opened with
+			              the age identity files of --identity or a passphrase
+			  json        JSON written by 'dwing creds export --format json'
+			  csv         CSV written by 'dwing creds export --format csv'
+			  bitwarden   Unencrypted Bitwarden JSON export
+			  1password   1Password CSV export
+			  keepass     KeePass 2 XML export, from KeePass or KeePassXC
+			  chrome      Chrome CSV export of saved passwords
+			  firefox     Firefox CSV export of saved logins
+			  dotenv      KEY=value lines, where DB_USER and DB_PASSWORD make up the
+			              credential nicknamed db and DB_HOST sets its host field
+
+			The environment of a credential is --env when given, else the
+			environment column or field of the file, else the folder or group of
+			the entry, else the configured default_environment. Entries are
+			nicknamed after their title.
+
+			A credential whose environment and username are already used is handled
+			by --on-duplicate: skip leaves it out, overwrite replaces the stored
+			secrets that the file has, such as the password, and keeps the rest of
+			the credential, and rename adds it with a " (2)" suffix on the username.
+
+			Nothing is written before the summary of the import is confirmed.
+			--dry-run only prints the summary and --yes skips the confirmation.
+
+			Available duplicate strategies: %s
+		`, strings.Join(transfer.OnDuplicateStrategies, ", ")),
+		Example: heredoc.Doc(`
+			$ dwing creds import --format bundle dwing-backup.age
+			$ dwing creds import --format bundle --identity ~/.config/age/key.txt prod.age
+			$ dwing creds import --format bitwarden bitwarden_export.json --dry-run
+			$ dwing creds import --format 1password -e dev 1password.csv
+			$ dwing creds import --format keepass --on-duplicate overwrite vault.xml
+			$ dwing creds import --format dotenv -e staging --yes - < .env.staging
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			entries, err := readImport(cmd, format, args[0], identities)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := cmdutil.Config(cmd)
+			if err != nil {
+				return err
+			}
+
+			envService, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			existing, err := service.ListCredentials("")
+			if err != nil {
+				return fmt.Errorf("failed to list credentials: %w", err)
+			}
+
+			steps, err := transfer.Plan(entries, existing, transfer.PlanOptions{
+				OnDuplicate: onDuplicate,
+				Environment: func(e transfer.Entry) (string, error) {
+					name := cmp.Or(env, e.Credential.Environment, e.Folder, cfg.DefaultEnvironment)
+					if name == "" {
+						return "", errors.New("no environment, pass --env")
+					}
+					return envService.Canonicalize(name)
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			shown := steps
+			if !printer.ShowSecrets {
+				shown = steps.Redacted()
+			}
+
+			if dryRun {
+				return printer.Print(shown, func(w io.Writer) error {
+					renderImportSummary(w, shown)
+					return nil
+				})
+			}
+
+			renderImportSummary(cmd.ErrOrStderr(), shown)
+
+			writes := steps.Writes()
+			if writes == 0 {
+				return printer.Success("Nothing to import", shown)
+			}
+
+			if err := cmdutil.Confirm(cmd, fmt.Sprintf("Import %d credentials?", writes), ""); err != nil {
+				return err
+			}
+
+			done, err := applyImport(service, steps)
+			if err != nil {
+				return fmt.Errorf("imported %d of %d credentials: %w", done, writes, err)
+			}
+
+			return printer.Success(fmt.Sprintf("Imported %d credentials", done), shown)
+		},
+	}
+
+	importCmd.Flags().StringVarP(&format, "format", "f", "", "Format of the file: "+strings.Join(importFormats(), ", "))
+	importCmd.Flags().StringVarP(&env, "env", "e", "", "Import every credential into this environment")
+	importCmd.Flags().StringVar(&onDuplicate, "on-duplicate", transfer.OnDuplicateSkip, "What to do with existing credentials: "+strings.Join(transfer.OnDuplicateStrategies, ", "))
+	importCmd.Flags().StringArrayVarP(&identities, "identity", "i", nil, "Open the bundle with this age identity file instead of a passphrase (repeatable)")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print what would be imported without writing anything")
+	cmdutil.AddYesFlag(importCmd)
+	_ = importCmd.MarkFlagRequired("format")
+
+	_ = importCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(importFormats(), cobra.ShellCompDirectiveNoFileComp))
+	_ = importCmd.RegisterFlagCompletionFunc("env", cmdutil.CompleteEnvironments)
+	_ = importCmd.RegisterFlagCompletionFunc("on-duplicate", cobra.FixedCompletions(transfer.OnDuplicateStrategies, cobra.ShellCompDirectiveNoFileComp))
+
+	return importCmd
+}
+
+// importFormats lists the formats transfer.Parse reads and encrypted
+// bundles.
+func importFormats() []string {
+	return append([]string{transfer.FormatBundle}, transfer.Formats...)
+}
+
+// readImport parses the file to import, or standard input when path is -.
+// Bundles are decrypted with the identity files, or a passphrase.
+func readImport(cmd *cobra.Command, format, path string, identities []string) ([]transfer.Entry, error) {
+	if len(identities) > 0 && format != transfer.FormatBundle {
+		return nil, errors.New("--identity requires --format bundle")
+	}
+
+	var r io.Reader = cmd.InOrStdin()
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if format == transfer.FormatBundle {
+		ageIdentities, err := bundleIdentities(identities)
+		if err != nil {
+			return nil, err
+		}
+		r, err = transfer.Decrypt(r, ageIdentities...)
+		if err != nil {
+			return nil, err
+		}
+		format = transfer.FormatJSON
+	}
+
+	return transfer.Parse(format, r)
+}
+
+// applyImport writes the steps that change the store and returns how many
+// were written.
+func applyImport(service *auth.CredentialService, steps transfer.Steps) (int, error) {
+	done := 0
+	for _, step := range steps {
+		var err error
+		switch step.Action {
+		case transfer.ActionAdd, transfer.ActionRename:
+			_, err = service.AddCredential(step.Credential)
+		case transfer.ActionOverwrite:
+			err = overwrite(service, step.Credential)
+		default:
+			continue
+		}
+		if err != nil {
+			return done, fmt.Errorf("%s: %w", step.Source, err)
+		}
+		done++
+	}
+	return done, nil
+}
+
+// overwrite replaces the secrets of the stored credential with the imported
+// ones and keeps the rest of it. The plan was made from a listing that
+// earlier steps have changed since, so the credential is read again.
+func overwrite(service *auth.CredentialService, cred auth.Credential) error {
+	current, err := service.ResolveWithSecrets(cred.ID)
+	if err != nil {
+		return err
+	}
+
+	current.MergeSecrets(cred)
+	return service.UpdateCredential(current)
+}
+
+func renderImportSummary(w io.Writer, steps transfer.Steps) {
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "No credentials found in the file.")
+		return
+	}
+
+	data := [][]string{}
+	for _, s := range steps {
+		c := s.Credential
+		data = append(data, []string{s.Action, c.Environment, c.Kind(), c.Username, c.Nickname, s.Source, s.Reason})
+	}
+
+	table := tablewriter.NewTable(w)
+	table.Header([]string{"Action", "Environment", "Type", "Username", "Nickname", "Source", "Reason"})
+	table.Bulk(data)
+	table.Render()
+
+	counts := map[string]int{}
+	for _, s := range steps {
+		counts[s.Action]++
+	}
+	var parts []string
+	for _, action := range []string{transfer.ActionAdd, transfer.ActionOverwrite, transfer.ActionRename, transfer.ActionSkip, transfer.ActionInvalid} {
+		if counts[action] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[action], action))
+		}
+	}
+	fmt.Fprintln(w, strings.Join(parts, ", "))
+}
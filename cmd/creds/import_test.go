@@ -0,0 +1,103 @@
+package creds
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/transfer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyImport(t *testing.T) {
+	service := auth.NewCredentialService(auth.NewJSONRepository(filepath.Join(t.TempDir(), "credentials.json")))
+
+	stored, err := service.AddCredential(auth.Credential{Environment: "dev", Username: "alice", Password: "old"})
+	require.NoError(t, err)
+
+	existing, err := service.ListCredentials("")
+	require.NoError(t, err)
+
+	steps, err := transfer.Plan([]transfer.Entry{
+		{Source: "a", Folder: "dev", Credential: auth.Credential{Username: "alice", Password: "new"}},
+		{Source: "b", Folder: "dev", Credential: auth.Credential{Username: "bob", Password: "pw"}},
+		{Source: "c", Folder: "dev", Credential: auth.Credential{Username: "carol"}},
+	}, existing, transfer.PlanOptions{
+		OnDuplicate: transfer.OnDuplicateOverwrite,
+		Environment: func(e transfer.Entry) (string, error) { return e.Folder, nil },
+	})
+	require.NoError(t, err)
+
+	done, err := applyImport(service, steps)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, done)
+
+	alice, err := service.ResolveWithSecrets(stored.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new", alice.Password)
+
+	creds, err := service.ListCredentials("dev")
+	require.NoError(t, err)
+	assert.Len(t, creds, 2)
+}
+
+func TestApplyImportOverwriteKeepsCredential(t *testing.T) {
+	service := auth.NewCredentialService(auth.NewJSONRepository(filepath.Join(t.TempDir(), "credentials.json")))
+
+	login, err := service.AddCredential(auth.Credential{Environment: "dev", Username: "alice", Password: "old", Nickname: "portal", OTPSeed: "JBSWY3DPEHPK3PXP"})
+	require.NoError(t, err)
+	key, err := service.AddCredential(auth.Credential{Environment: "dev", Type: auth.TypeAPIKey, Username: "ci", Password: "old-key", Fields: map[string]string{"header": "X-API-Key"}})
+	require.NoError(t, err)
+
+	existing, err := service.ListCredentials("")
+	require.NoError(t, err)
+
+	steps, err := transfer.Plan([]transfer.Entry{
+		{Source: "a", Folder: "dev", Credential: auth.Credential{Username: "alice", Password: "new"}},
+		{Source: "b", Folder: "dev", Credential: auth.Credential{Username: "ci", Password: "new-key"}},
+	}, existing, transfer.PlanOptions{
+		OnDuplicate: transfer.OnDuplicateOverwrite,
+		Environment: func(e transfer.Entry) (string, error) { return e.Folder, nil },
+	})
+	require.NoError(t, err)
+
+	_, err = applyImport(service, steps)
+	require.NoError(t, err)
+
+	got, err := service.ResolveWithSecrets(login.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new", got.Password)
+	assert.Equal(t, "portal", got.Nickname)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", got.OTPSeed)
+
+	got, err = service.ResolveWithSecrets(key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-key", got.Password)
+	assert.Equal(t, auth.TypeAPIKey, got.Type)
+	assert.Equal(t, map[string]string{"header": "X-API-Key"}, got.Fields)
+}
+
+func TestRenderImportSummary(t *testing.T) {
+	t.Run("Counts actions", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderImportSummary(&buf, transfer.Steps{
+			{Action: transfer.ActionAdd, Credential: auth.Credential{Environment: "dev", Username: "alice"}},
+			{Action: transfer.ActionAdd, Credential: auth.Credential{Environment: "dev", Username: "bob"}},
+			{Action: transfer.ActionInvalid, Credential: auth.Credential{Username: "carol"}, Reason: "environment is required"},
+		})
+
+		assert.Contains(t, buf.String(), "environment is required")
+		assert.Contains(t, buf.String(), "2 add, 1 invalid\n")
+	})
+
+	t.Run("Empty file", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderImportSummary(&buf, nil)
+
+		assert.Equal(t, "No credentials found in the file.\n", buf.String())
+	})
+}
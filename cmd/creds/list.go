@@ -2,9 +2,9 @@ package creds
 
 import (
 	"fmt"
+	"io"
 	"jpellissari/dwing/internal/auth"
-	"jpellissari/dwing/internal/config"
-	"os"
+	"jpellissari/dwing/internal/cmdutil"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/olekukonko/tablewriter"
@@ -22,48 +22,71 @@ func NewCredsListCommand() *cobra.Command {
 		Example: heredoc.Doc(`
 			$ dwing creds list [--env <environment>]
 			$ dwing creds ls [-e <environment>]
+			$ dwing creds ls -o json
+			$ dwing creds ls -o jsonpath='{range [*]}{.id}{"\n"}{end}'
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.NewDefaultConfig()
+			printer, err := cmdutil.NewPrinter(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to load config: %w", err)
+				return err
 			}
 
-			repo := auth.NewJSONRepository(cfg.CredentialsPath)
-			service := auth.NewCredentialService(repo)
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			if env != "" {
+				envService, err := cmdutil.NewEnvironmentService(cmd)
+				if err != nil {
+					return err
+				}
+
+				env, err = envService.Canonicalize(env)
+				if err != nil {
+					return err
+				}
+			}
 
 			creds, err := service.ListCredentials(env)
 			if err != nil {
 				return fmt.Errorf("failed to list credentials: %w", err)
 			}
 
-			renderTable(creds)
+			if !printer.ShowSecrets {
+				creds = creds.Redacted()
+			}
 
-			return nil
+			return printer.Print(creds, func(w io.Writer) error {
+				renderTable(w, creds)
+				return nil
+			})
 		},
 	}
 
 	listCmd.Flags().StringVarP(&env, "env", "e", "", "Filter credentials by environment")
 
+	_ = listCmd.RegisterFlagCompletionFunc("env", cmdutil.CompleteEnvironments)
+
 	return listCmd
 }
 
-func renderTable(creds auth.Credentials) {
+func renderTable(w io.Writer, creds auth.Credentials) {
 	if len(creds) == 0 {
-		fmt.Println("No credentials found.")
-		fmt.Println("Try adding some with 'dwing creds add'")
+		fmt.Fprintln(w, "No credentials found.")
+		fmt.Fprintln(w, "Try adding some with 'dwing creds add'")
 		return
 	}
 
-	header := []string{"ID", "Environment", "Username", "Nickname"}
+	header := []string{"ID", "Environment", "Type", "Username", "Nickname"}
 
 	data := [][]string{}
 	for _, c := range creds {
-		row := []string{c.ID, c.Environment, c.Username, c.Nickname}
+		row := []string{c.ID, c.Environment, c.Kind(), c.Username, c.Nickname}
 		data = append(data, row)
 	}
 
-	table := tablewriter.NewTable(os.Stdout)
+	table := tablewriter.NewTable(w)
 	table.Header(header)
 	table.Bulk(data)
 	table.Render()
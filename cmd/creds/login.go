@@ -0,0 +1,95 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/login"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewCredsLoginCommand() *cobra.Command {
+	var loginCmd = &cobra.Command{
+		Use:   "login <credential_id>",
+		Short: "Generate an access token for a stored credential",
+		Long: heredoc.Doc(`
+			Run the login flow for a stored credential and print the resulting access
+			token. Login settings come from the credential's environment and can be
+			overridden with flags.
+
+			With the authorization_code flow you log in with the identity provider
+			in a browser, which dwing opens on the authorization URL. It waits for
+			the browser to come back to a listener on 127.0.0.1, for up to 5 minutes.
+			The credential's username is sent as a login hint.
+
+			The device_code flow suits SSH sessions without a browser: it prints a
+			URL and a code to enter on any other device, and waits for the login
+			to be approved there.
+
+			Environments with a login recipe run it instead, see 'dwing env add'.
+
+			The token is cached on the credential, encrypted like its other secrets,
+			so 'dwing token' can reuse or refresh it.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing creds login <credential_id>
+			$ dwing creds login <credential_id> --token-url https://idp.dev.example.com/oauth/token
+			$ dwing creds login <credential_id> --token-url <url> --client-id my-app --scope openid
+			$ dwing creds login <credential_id> --otp-field totp
+			$ dwing creds login <credential_id> --flow client_credentials
+			$ dwing creds login <credential_id> --flow authorization_code --authorize-url <url> --token-url <url>
+			$ dwing creds login <credential_id> --flow device_code --device-url <url> --token-url <url>
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			cred, err := service.ResolveWithSecrets(id)
+			if err != nil {
+				if errors.Is(err, auth.ErrCredentialNotFound) {
+					return fmt.Errorf("credential '%s' not found", id)
+				}
+				return fmt.Errorf("failed to get credential: %w", err)
+			}
+
+			flow, err := cmdutil.NewLoginFlow(cmd, cred)
+			if err != nil {
+				return err
+			}
+
+			token, err := flow.Login(cmd.Context(), cred)
+			if err != nil {
+				return fmt.Errorf("failed to login: %w", err)
+			}
+			token = login.WithJWTExpiry(token)
+
+			if err := service.CacheToken(cred.ID, &token); err != nil {
+				return err
+			}
+
+			return printer.Print(token, func(w io.Writer) error {
+				_, err := fmt.Fprintln(w, token.AccessToken)
+				return err
+			})
+		},
+	}
+
+	cmdutil.AddLoginFlags(loginCmd)
+
+	return loginCmd
+}
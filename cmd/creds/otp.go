@@ -0,0 +1,91 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/otp"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+type otpCode struct {
+	Code      string    `json:"code"`
+	Remaining int       `json:"remaining_seconds"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewCredsOTPCommand() *cobra.Command {
+	var otpCmd = &cobra.Command{
+		Use:   "otp <credential_id>",
+		Short: "Print the current TOTP code of a credential",
+		Long:  `Print the current time-based one-time password (RFC 6238) of a credential with an OTP seed, and how many seconds it stays valid.`,
+		Example: heredoc.Doc(`
+			$ dwing creds otp <credential_id>
+			$ dwing creds otp dev/alice -o jsonpath='{.code}'
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			cred, err := service.ResolveWithSecrets(id)
+			if err != nil {
+				if errors.Is(err, auth.ErrCredentialNotFound) {
+					return fmt.Errorf("credential '%s' not found", id)
+				}
+				return fmt.Errorf("failed to get credential: %w", err)
+			}
+
+			if cred.OTPSeed == "" {
+				return fmt.Errorf("credential '%s' has no OTP seed, add one with 'dwing creds edit %s --otp-seed <seed>'", id, id)
+			}
+
+			result, err := currentOTP(cred.OTPSeed, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return printer.Print(result, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "%s (%ds left)\n", result.Code, result.Remaining)
+				return err
+			})
+		},
+	}
+
+	return otpCmd
+}
+
+func currentOTP(seed string, now time.Time) (otpCode, error) {
+	key, err := otp.Parse(seed)
+	if err != nil {
+		return otpCode{}, err
+	}
+
+	code, err := key.Code(now)
+	if err != nil {
+		return otpCode{}, err
+	}
+
+	remaining := key.Remaining(now)
+
+	return otpCode{
+		Code:      code,
+		Remaining: int(remaining.Seconds()),
+		ExpiresAt: now.Truncate(time.Second).Add(remaining),
+	}, nil
+}
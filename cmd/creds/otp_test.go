@@ -0,0 +1,21 @@
+package creds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrentOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	got, err := currentOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", now)
+
+	require.NoError(t, err)
+	assert.Equal(t, otpCode{Code: "081804", Remaining: 1, ExpiresAt: time.Unix(1111111110, 0)}, got)
+
+	_, err = currentOTP("not base32!", now)
+	assert.Error(t, err)
+}
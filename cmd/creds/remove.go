@@ -4,7 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"jpellissari/dwing/internal/auth"
-	"jpellissari/dwing/internal/config"
+	"jpellissari/dwing/internal/cmdutil"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -21,32 +21,39 @@ func NewCredsRemoveCommand() *cobra.Command {
 		Example: heredoc.Doc(`
 			$ dwing creds remove <credential_id>
 			$ dwing creds rm <credential_id>
+			$ dwing creds rm 3f2a
+			$ dwing creds rm dev/alice
 		`),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return fmt.Errorf("credential ID is required")
 			}
 			id = args[0]
 
-			cfg, err := config.NewDefaultConfig()
+			printer, err := cmdutil.NewPrinter(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to load config: %w", err)
+				return err
 			}
 
-			repo := auth.NewJSONRepository(cfg.CredentialsPath)
-			service := auth.NewCredentialService(repo)
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
 
-			if err := service.RemoveCredential(id); err != nil {
+			cred, err := service.Resolve(id)
+			if err != nil {
 				if errors.Is(err, auth.ErrCredentialNotFound) {
-					fmt.Printf("❌ Credential with ID '%s' not found\n", id)
-					return nil
+					return printer.Failure(fmt.Sprintf("Credential '%s' not found", id))
 				}
-				return fmt.Errorf("failed to remove credential: %w", err)
+				return fmt.Errorf("failed to get credential: %w", err)
 			}
 
-			fmt.Println("Credential removed successfully")
+			if err := service.RemoveCredential(cred.ID); err != nil {
+				return fmt.Errorf("failed to remove credential: %w", err)
+			}
 
-			return nil
+			return printer.Success("Credential removed successfully", nil)
 		},
 	}
 
@@ -0,0 +1,136 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewCredsShowCommand() *cobra.Command {
+	var reveal bool
+
+	var showCmd = &cobra.Command{
+		Use:   "show <credential_id> [flags]",
+		Short: "Show a stored credential",
+		Long: heredoc.Doc(`
+			Show every field of a stored credential. Secrets are masked unless --reveal
+			is given.
+
+			Revealing the secrets of a credential in a high danger environment asks
+			for confirmation first, which --yes skips.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing creds show dev/alice
+			$ dwing creds show dev/alice --reveal
+			$ dwing creds show prod/deploy --reveal --yes -o json
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			cred, err := service.ResolveWithSecrets(id)
+			if err != nil {
+				if errors.Is(err, auth.ErrCredentialNotFound) {
+					return fmt.Errorf("credential '%s' not found", id)
+				}
+				return fmt.Errorf("failed to get credential: %w", err)
+			}
+
+			envService, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			env, err := envService.GetEnvironment(cred.Environment)
+			if err != nil && !errors.Is(err, environment.ErrEnvironmentNotFound) {
+				return err
+			}
+
+			if reveal || printer.ShowSecrets {
+				if env.IsDangerous() {
+					err := cmdutil.Confirm(cmd,
+						fmt.Sprintf("Reveal the secrets of (%s) - %s?", cred.Environment, cred.Username),
+						fmt.Sprintf("%s is a high danger environment.", cred.Environment))
+					if err != nil {
+						return err
+					}
+				}
+			} else {
+				cred = cred.Redacted()
+			}
+
+			return printer.Print(cred, func(w io.Writer) error {
+				return renderCredential(w, cred, env)
+			})
+		},
+	}
+
+	showCmd.Flags().BoolVar(&reveal, "reveal", false, "Show secrets instead of masking them")
+	cmdutil.AddYesFlag(showCmd)
+
+	return showCmd
+}
+
+// renderCredential prints the fields of the credential's type under their
+// labels, leaving out optional fields that are not set. Multiline values,
+// such as private keys, start on their own line.
+func renderCredential(w io.Writer, cred auth.Credential, env environment.Environment) error {
+	t, err := auth.LookupType(cred.Type)
+	if err != nil {
+		return err
+	}
+
+	envLabel := cred.Environment
+	if env.DangerLevel != "" {
+		envLabel += " (danger: " + env.DangerLevel + ")"
+	}
+
+	rows := [][2]string{
+		{"ID", cred.ID},
+		{"Environment", envLabel},
+		{"Type", t.Name},
+		{"Nickname", cred.Nickname},
+	}
+	for _, f := range t.Fields {
+		if value := cred.Get(f.Name); value != "" || f.Required {
+			rows = append(rows, [2]string{f.Label, value})
+		}
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+
+	for _, row := range rows {
+		label, value := row[0]+":", row[1]
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(w, "%s\n", label)
+			for _, line := range strings.Split(value, "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%-*s %s\n", width+1, label, value)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package creds
+
+import (
+	"bytes"
+	"testing"
+
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/environment"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCredential(t *testing.T) {
+	t.Run("Password credential", func(t *testing.T) {
+		cred := auth.Credential{ID: "1", Environment: "prod", Username: "alice", Password: "s3cret", Nickname: "admin"}
+		env := environment.Environment{Name: "prod", DangerLevel: environment.DangerHigh}
+
+		var buf bytes.Buffer
+		require.NoError(t, renderCredential(&buf, cred.Redacted(), env))
+
+		assert.Equal(t, heredoc.Doc(`
+			ID:          1
+			Environment: prod (danger: high)
+			Type:        password
+			Nickname:    admin
+			Username:    alice
+			Password:    ********
+		`), buf.String())
+	})
+
+	t.Run("Multiline values start on their own line", func(t *testing.T) {
+		cred := auth.Credential{
+			ID:          "2",
+			Environment: "dev",
+			Type:        auth.TypeSSHKey,
+			Username:    "deploy",
+			Fields:      map[string]string{"private_key": "-----BEGIN KEY-----\nabc\n-----END KEY-----", "host": "bastion"},
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, renderCredential(&buf, cred, environment.Environment{}))
+
+		assert.NotContains(t, buf.String(), "Key passphrase")
+		assert.Contains(t, buf.String(), "Private key:\n  -----BEGIN KEY-----\n  abc\n  -----END KEY-----\n")
+		assert.Contains(t, buf.String(), "Environment: dev\n")
+		assert.Contains(t, buf.String(), "Host:        bastion\n")
+	})
+}
@@ -0,0 +1,126 @@
+package env
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/login"
+	"jpellissari/dwing/internal/passgen"
+	"jpellissari/dwing/internal/recipe"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewEnvAddCommand() *cobra.Command {
+	var env = environment.Environment{}
+	var recipeFile string
+
+	var addCmd = &cobra.Command{
+		Use:   "add <name> [flags]",
+		Short: "Add a new environment",
+		Long: heredoc.Doc(`
+			Register a new environment with its aliases, endpoints, login settings,
+			password policy and danger level.
+
+			APIs with a bespoke login endpoint get a login recipe with --recipe: a
+			YAML file of HTTP steps whose URLs, headers and bodies are templates
+			over the credential, such as {{.Username}}, {{.Password}}, {{.OTP}} or
+			{{.BaseURL}}. Each step can extract values from its response with a
+			JSONPath, a header or a cookie into {{.Vars.<name>}}, for the following
+			steps and the resulting token:
+
+			  steps:
+			    - name: login
+			      url: "{{.BaseURL}}/api/login"
+			      body: '{"user": {{json .Username}}, "password": {{json .Password}}}'
+			      extract:
+			        token: {jsonpath: .data.token}
+			        ttl: {jsonpath: .data.ttl}
+			  token:
+			    access_token: "{{.Vars.token}}"
+			    expires_in: "{{.Vars.ttl}}"
+		`),
+		Example: heredoc.Doc(`
+			$ dwing env add dev --base-url https://api.dev.example.com
+			$ dwing env add prod --alias production --alias prd --danger-level high \
+				--token-url https://idp.example.com/oauth/token --client-id dwing --scope openid \
+				--jwks-url https://idp.example.com/.well-known/jwks.json \
+				--gen-length 32 --gen-exclude-ambiguous
+			$ dwing env add staging --flow authorization_code --client-id dwing --scope openid \
+				--authorize-url https://idp.example.com/oauth/authorize --token-url https://idp.example.com/oauth/token
+			$ dwing env add bastion --flow device_code --client-id dwing \
+				--device-url https://idp.example.com/oauth/device --token-url https://idp.example.com/oauth/token
+			$ dwing env add legacy --base-url https://legacy.example.com --recipe legacy-login.yaml
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env.Name = args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			if recipeFile != "" {
+				data, err := os.ReadFile(recipeFile)
+				if err != nil {
+					return fmt.Errorf("failed to read recipe: %w", err)
+				}
+				env.Login.Recipe, err = recipe.Parse(data)
+				if err != nil {
+					return err
+				}
+				if env.Login.Flow == "" {
+					env.Login.Flow = login.FlowRecipe
+				}
+			}
+
+			if _, err := login.NewFlow(login.Settings{Flow: env.Login.Flow, Recipe: env.Login.Recipe}); err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := service.AddEnvironment(env); err != nil {
+				return fmt.Errorf("failed to add environment: %w", err)
+			}
+
+			added, err := service.GetEnvironment(env.Name)
+			if err != nil {
+				return err
+			}
+
+			return printer.Success(fmt.Sprintf("Environment added successfully: %s", env.Name), added)
+		},
+	}
+
+	addCmd.Flags().StringSliceVarP(&env.Aliases, "alias", "a", nil, "Alternative name for the environment (repeatable)")
+	addCmd.Flags().StringVar(&env.BaseURL, "base-url", "", "Base URL of the environment's APIs")
+	addCmd.Flags().StringVar(&env.DangerLevel, "danger-level", environment.DangerLow, "Danger level: "+strings.Join(environment.DangerLevels, ", "))
+	addCmd.Flags().StringVar(&env.Login.Flow, "flow", "", "Login flow used by 'dwing creds login' and 'dwing token': "+strings.Join(login.Flows, ", "))
+	addCmd.Flags().StringVar(&env.Login.AuthorizeURL, "authorize-url", "", "OAuth2 authorization endpoint, for the authorization_code flow")
+	addCmd.Flags().StringVar(&env.Login.DeviceURL, "device-url", "", "OAuth2 device authorization endpoint, for the device_code flow")
+	addCmd.Flags().StringVar(&env.Login.TokenURL, "token-url", "", "OAuth2 token endpoint")
+	addCmd.Flags().StringVar(&env.Login.ClientID, "client-id", "", "OAuth2 client ID")
+	addCmd.Flags().StringSliceVar(&env.Login.Scopes, "scope", nil, "OAuth2 scopes to request")
+	addCmd.Flags().StringVar(&env.Login.JWKSURL, "jwks-url", "", "URL or file of the JWKS that signs tokens, for 'dwing token inspect'")
+	addCmd.Flags().StringVar(&recipeFile, "recipe", "", "YAML file with the login recipe, for the recipe flow")
+	addCmd.Flags().StringVar(&env.Login.OTPField, "otp-field", "", "Form field the credential's TOTP code is sent in on login")
+	addCmd.Flags().IntVar(&env.PasswordPolicy.Length, "gen-length", 0, "Length, and minimum length, of generated passwords")
+	addCmd.Flags().StringSliceVar(&env.PasswordPolicy.Classes, "gen-classes", nil, "Character classes of generated passwords: "+strings.Join(passgen.Classes, ", "))
+	addCmd.Flags().BoolVar(&env.PasswordPolicy.ExcludeAmbiguous, "gen-exclude-ambiguous", false, "Leave easily confused characters out of generated passwords")
+	addCmd.Flags().BoolVar(&env.PasswordPolicy.Passphrase, "gen-passphrase", false, "Generate diceware passphrases instead of passwords")
+	addCmd.Flags().IntVar(&env.PasswordPolicy.Words, "gen-words", 0, "Number, and minimum number, of words of generated passphrases")
+
+	_ = addCmd.RegisterFlagCompletionFunc("flow", cobra.FixedCompletions(login.Flows, cobra.ShellCompDirectiveNoFileComp))
+	_ = addCmd.RegisterFlagCompletionFunc("danger-level", cobra.FixedCompletions(environment.DangerLevels, cobra.ShellCompDirectiveNoFileComp))
+	_ = addCmd.RegisterFlagCompletionFunc("gen-classes", cobra.FixedCompletions(passgen.Classes, cobra.ShellCompDirectiveNoFileComp))
+
+	return addCmd
+}
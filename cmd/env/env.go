@@ -0,0 +1,65 @@
+package env
+
+import (
+	"jpellissari/dwing/internal/cmdutil"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewEnvCmd() *cobra.Command {
+	var envCmd = &cobra.Command{
+		Use:   "env <command> [flags]",
+		Short: "Manage your environments",
+		Long: heredoc.Doc(`
+			Manage the environments your credentials belong to.
+
+			An environment has a canonical name, optional aliases, a base URL, the
+			settings used by 'dwing creds login' and a danger level. Once at least one
+			environment is registered, credentials can only be added to known
+			environments, and aliases such as "production" resolve to their canonical
+			name.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing env ls
+			$ dwing env add prod --alias production --danger-level high
+			$ dwing env show prod
+			$ dwing env rm prod
+		`),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	envGroup := cobra.Group{
+		ID:    "env",
+		Title: "Environment Management",
+	}
+	envCmd.AddGroup(&envGroup)
+
+	envAddCmd := NewEnvAddCommand()
+	envAddCmd.GroupID = envGroup.ID
+
+	envListCmd := NewEnvListCommand()
+	envListCmd.GroupID = envGroup.ID
+
+	envRemoveCmd := NewEnvRemoveCommand()
+	envRemoveCmd.GroupID = envGroup.ID
+
+	envShowCmd := NewEnvShowCommand()
+	envShowCmd.GroupID = envGroup.ID
+
+	envCmd.AddCommand(envAddCmd)
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envRemoveCmd)
+	envCmd.AddCommand(envShowCmd)
+
+	return envCmd
+}
+
+func completeEnvironmentArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cmdutil.CompleteEnvironments(cmd, args, toComplete)
+}
@@ -0,0 +1,71 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func NewEnvListCommand() *cobra.Command {
+	var listCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List all environments",
+		Long:    `List all environments known to dwing.`,
+		Aliases: []string{"ls"},
+		Example: heredoc.Doc(`
+			$ dwing env list
+			$ dwing env ls -o json
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			envs, err := service.ListEnvironments()
+			if err != nil {
+				return fmt.Errorf("failed to list environments: %w", err)
+			}
+
+			return printer.Print(envs, func(w io.Writer) error {
+				renderTable(w, envs)
+				return nil
+			})
+		},
+	}
+
+	return listCmd
+}
+
+func renderTable(w io.Writer, envs environment.Environments) {
+	if len(envs) == 0 {
+		fmt.Fprintln(w, "No environments found.")
+		fmt.Fprintln(w, "Try adding some with 'dwing env add'")
+		return
+	}
+
+	header := []string{"Name", "Aliases", "Base URL", "Login Flow", "Danger Level"}
+
+	data := [][]string{}
+	for _, e := range envs {
+		row := []string{e.Name, strings.Join(e.Aliases, ", "), e.BaseURL, e.Login.Flow, e.DangerLevel}
+		data = append(data, row)
+	}
+
+	table := tablewriter.NewTable(w)
+	table.Header(header)
+	table.Bulk(data)
+	table.Render()
+}
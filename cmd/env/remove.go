@@ -0,0 +1,50 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewEnvRemoveCommand() *cobra.Command {
+	var removeCmd = &cobra.Command{
+		Use:     "remove <name>",
+		Short:   "Remove an environment",
+		Long:    `Remove an environment. Credentials of the environment are kept.`,
+		Aliases: []string{"rm"},
+		Example: heredoc.Doc(`
+			$ dwing env remove staging
+			$ dwing env rm staging
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeEnvironmentArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := service.RemoveEnvironment(name); err != nil {
+				if errors.Is(err, environment.ErrEnvironmentNotFound) {
+					return fmt.Errorf("environment '%s' not found", name)
+				}
+				return fmt.Errorf("failed to remove environment: %w", err)
+			}
+
+			return printer.Success("Environment removed successfully", nil)
+		},
+	}
+
+	return removeCmd
+}
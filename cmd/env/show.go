@@ -0,0 +1,113 @@
+package env
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/passgen"
+	"jpellissari/dwing/internal/recipe"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewEnvShowCommand() *cobra.Command {
+	var showCmd = &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show an environment",
+		Long:  `Show every setting of an environment, looked up by name or alias.`,
+		Example: heredoc.Doc(`
+			$ dwing env show prod
+			$ dwing env show production -o yaml
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeEnvironmentArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			env, err := service.GetEnvironment(name)
+			if err != nil {
+				if errors.Is(err, environment.ErrEnvironmentNotFound) {
+					return fmt.Errorf("environment '%s' not found", name)
+				}
+				return fmt.Errorf("failed to get environment: %w", err)
+			}
+
+			return printer.Print(env, func(w io.Writer) error {
+				renderDetails(w, env)
+				return nil
+			})
+		},
+	}
+
+	return showCmd
+}
+
+func renderDetails(w io.Writer, env environment.Environment) {
+	fmt.Fprintf(w, "Name:          %s\n", env.Name)
+	fmt.Fprintf(w, "Aliases:       %s\n", strings.Join(env.Aliases, ", "))
+	fmt.Fprintf(w, "Base URL:      %s\n", env.BaseURL)
+	fmt.Fprintf(w, "Danger level:  %s\n", env.DangerLevel)
+	fmt.Fprintf(w, "Login flow:    %s\n", env.Login.Flow)
+	fmt.Fprintf(w, "Authorize URL: %s\n", env.Login.AuthorizeURL)
+	fmt.Fprintf(w, "Device URL:    %s\n", env.Login.DeviceURL)
+	fmt.Fprintf(w, "Token URL:     %s\n", env.Login.TokenURL)
+	fmt.Fprintf(w, "Client ID:     %s\n", env.Login.ClientID)
+	fmt.Fprintf(w, "Scopes:        %s\n", strings.Join(env.Login.Scopes, " "))
+	fmt.Fprintf(w, "OTP field:     %s\n", env.Login.OTPField)
+	fmt.Fprintf(w, "JWKS URL:      %s\n", env.Login.JWKSURL)
+	fmt.Fprintf(w, "Login recipe:  %s\n", describeRecipe(env.Login.Recipe))
+	fmt.Fprintf(w, "Generator:     %s\n", describePolicy(env.PasswordPolicy))
+}
+
+func describeRecipe(r *recipe.Recipe) string {
+	if r == nil {
+		return ""
+	}
+
+	steps := make([]string, 0, len(r.Steps))
+	for i, s := range r.Steps {
+		steps = append(steps, cmp.Or(s.Name, strconv.Itoa(i+1)))
+	}
+	return "steps " + strings.Join(steps, ", ")
+}
+
+func describePolicy(p environment.PasswordPolicy) string {
+	if p.Passphrase {
+		words := p.Words
+		if words == 0 {
+			words = passgen.DefaultWords
+		}
+		return fmt.Sprintf("passphrase, %d words", words)
+	}
+
+	length := p.Length
+	if length == 0 {
+		length = passgen.DefaultLength
+	}
+	classes := p.Classes
+	if len(classes) == 0 {
+		classes = passgen.Classes
+	}
+
+	description := fmt.Sprintf("password, %d characters, %s", length, strings.Join(classes, ", "))
+	if p.ExcludeAmbiguous {
+		description += ", no ambiguous characters"
+	}
+	return description
+}
@@ -0,0 +1,34 @@
+package gen
+
+import (
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewGenCmd() *cobra.Command {
+	var genCmd = &cobra.Command{
+		Use:   "gen <command> [flags]",
+		Short: "Generate passwords and passphrases",
+		Long: heredoc.Doc(`
+			Generate strong passwords and diceware passphrases from a cryptographically
+			secure random source.
+
+			With --env, the password policy of the environment sets the defaults and
+			the minimum length. To store a generated secret right away use
+			'dwing creds add --generate' or 'dwing creds edit --rotate'.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing gen password
+			$ dwing gen password --length 32 --exclude-ambiguous
+			$ dwing gen passphrase --words 7
+		`),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	genCmd.AddCommand(NewGenPasswordCommand())
+	genCmd.AddCommand(NewGenPassphraseCommand())
+
+	return genCmd
+}
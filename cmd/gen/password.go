@@ -0,0 +1,107 @@
+package gen
+
+import (
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/cmdutil"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+type generated struct {
+	Secret string `json:"secret"`
+}
+
+func NewGenPasswordCommand() *cobra.Command {
+	var env string
+
+	var passwordCmd = &cobra.Command{
+		Use:   "password [flags]",
+		Short: "Generate a random password",
+		Long: heredoc.Doc(`
+			Generate a random password with at least one character of every requested
+			class. Use --passphrase for a diceware passphrase instead.
+
+			The password is printed unless --copy is given, in which case it is only
+			copied to the clipboard and cleared after clipboard_timeout.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing gen password
+			$ dwing gen password --length 32 --classes lower,upper,digits
+			$ dwing gen password --env prod --copy
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return generate(cmd, env)
+		},
+	}
+
+	passwordCmd.Flags().StringVarP(&env, "env", "e", "", "Follow the password policy of this environment")
+	cmdutil.AddGeneratorFlags(passwordCmd)
+
+	_ = passwordCmd.RegisterFlagCompletionFunc("env", cmdutil.CompleteEnvironments)
+
+	return passwordCmd
+}
+
+func NewGenPassphraseCommand() *cobra.Command {
+	var env string
+
+	var passphraseCmd = &cobra.Command{
+		Use:   "passphrase [flags]",
+		Short: "Generate a diceware passphrase",
+		Long: heredoc.Doc(`
+			Generate a diceware passphrase from the EFF large wordlist. Every word adds
+			about 12.9 bits of entropy, so the default of 6 words gives 77 bits.
+
+			The passphrase is printed unless --copy is given, in which case it is only
+			copied to the clipboard and cleared after clipboard_timeout.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing gen passphrase
+			$ dwing gen passphrase --words 8 --separator " "
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.Flags().Set(cmdutil.PassphraseFlag, "true"); err != nil {
+				return err
+			}
+			return generate(cmd, env)
+		},
+	}
+
+	passphraseCmd.Flags().StringVarP(&env, "env", "e", "", "Follow the password policy of this environment")
+	cmdutil.AddGeneratorFlags(passphraseCmd)
+	_ = passphraseCmd.Flags().MarkHidden(cmdutil.PassphraseFlag)
+
+	_ = passphraseCmd.RegisterFlagCompletionFunc("env", cmdutil.CompleteEnvironments)
+
+	return passphraseCmd
+}
+
+func generate(cmd *cobra.Command, env string) error {
+	printer, err := cmdutil.NewPrinter(cmd)
+	if err != nil {
+		return err
+	}
+
+	secret, err := cmdutil.GenerateSecret(cmd, env)
+	if err != nil {
+		return err
+	}
+
+	if toClipboard, _ := cmd.Flags().GetBool(cmdutil.CopyFlag); toClipboard {
+		copied, err := cmdutil.CopySecret(cmd, secret)
+		if err != nil {
+			return err
+		}
+		return printer.Success(fmt.Sprintf("Generated secret %s (%d characters)", copied, len(secret)), nil)
+	}
+
+	result := generated{Secret: secret}
+	return printer.Print(result, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, result.Secret)
+		return err
+	})
+}
@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"jpellissari/dwing/cmd/agent"
+	"jpellissari/dwing/cmd/completion"
+	"jpellissari/dwing/cmd/config"
 	"jpellissari/dwing/cmd/creds"
+	"jpellissari/dwing/cmd/env"
+	"jpellissari/dwing/cmd/gen"
+	"jpellissari/dwing/cmd/run"
+	"jpellissari/dwing/cmd/token"
+	"jpellissari/dwing/internal/cmdutil"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -14,13 +22,27 @@ func NewCmdRoot() *cobra.Command {
 		Long:  `Dwing is your developer wingman, designed to make you faster on your day-to-day tasks.`,
 		Example: heredoc.Doc(`
 			$dwing creds ls
+			$dwing creds ls -o json
 		`),
+		PersistentPreRunE: cmdutil.LoadConfig,
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd.Help()
 		},
 	}
 
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	cmdutil.AddConfigFlag(rootCmd)
+	cmdutil.AddOutputFlags(rootCmd)
+
 	rootCmd.AddCommand(creds.NewCredsCmd())
+	rootCmd.AddCommand(env.NewEnvCmd())
+	rootCmd.AddCommand(agent.NewAgentCmd())
+	rootCmd.AddCommand(run.NewRunCmd())
+	rootCmd.AddCommand(token.NewTokenCmd())
+	rootCmd.AddCommand(gen.NewGenCmd())
+	rootCmd.AddCommand(config.NewConfigCmd())
+	rootCmd.AddCommand(completion.NewCompletionCmd())
 
 	return rootCmd
 }
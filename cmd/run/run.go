@@ -0,0 +1,97 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/runner"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewRunCmd() *cobra.Command {
+	var credRefs []string
+
+	var runCmd = &cobra.Command{
+		Use:   "run --cred <credential> [--cred <credential>...] -- <command> [args...]",
+		Short: "Run a command with credentials in its environment",
+		Long: heredoc.Doc(`
+			Run a command with stored credentials injected as environment variables.
+
+			By default the username and password are exported as DWING_USERNAME and
+			DWING_PASSWORD. Map fields to other variables with
+			--cred <credential>,<field>=<VAR>. Available fields are id, environment,
+			username, password, nickname and otp, the current TOTP code, plus the
+			fields of the credential's type, such as host or port for a database.
+
+			Flags after the command belong to it, so the -- separator is optional.
+			Signals are forwarded to the command and its exit code is passed through.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing run --cred deploy-bot -- ./deploy.sh
+			$ dwing run --cred db,username=PGUSER,password=PGPASSWORD -- psql
+			$ dwing run --cred db,username=PGUSER,password=PGPASSWORD psql -c 'select 1'
+			$ dwing run --cred db,username=PGUSER,password=PGPASSWORD --cred api -- ./migrate.sh
+			$ dwing run --cred db,host=PGHOST,port=PGPORT,username=PGUSER,password=PGPASSWORD -- psql
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(credRefs) == 0 {
+				return errors.New("at least one --cred is required")
+			}
+
+			bindings := make([]runner.Binding, 0, len(credRefs))
+			for _, ref := range credRefs {
+				b, err := runner.ParseBinding(ref)
+				if err != nil {
+					return err
+				}
+				bindings = append(bindings, b)
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			creds := make(auth.Credentials, 0, len(bindings))
+			for _, b := range bindings {
+				cred, err := service.ResolveWithSecrets(b.Ref)
+				if err != nil {
+					if errors.Is(err, auth.ErrCredentialNotFound) {
+						return fmt.Errorf("credential '%s' not found", b.Ref)
+					}
+					return fmt.Errorf("failed to get credential: %w", err)
+				}
+				creds = append(creds, cred)
+			}
+
+			env, err := runner.Environ(bindings, creds)
+			if err != nil {
+				return err
+			}
+
+			code, err := runner.Run(args, env)
+			if err != nil {
+				return err
+			}
+
+			if code != 0 {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+				return &cmdutil.ExitError{Code: code}
+			}
+
+			return nil
+		},
+	}
+
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().StringArrayVarP(&credRefs, "cred", "c", nil, "Credential ID or nickname to inject, optionally with <field>=<VAR> mappings")
+
+	_ = runCmd.RegisterFlagCompletionFunc("cred", cmdutil.CompleteCredentialRefs)
+
+	return runCmd
+}
@@ -0,0 +1,243 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/jwt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+const (
+	signatureVerified   = "verified"
+	signatureInvalid    = "invalid"
+	signatureUnverified = "unverified"
+)
+
+// inspection is what 'dwing token inspect' reports about a JWT. The token
+// itself is never part of it.
+type inspection struct {
+	Header         map[string]any `json:"header"`
+	Claims         map[string]any `json:"claims"`
+	IssuedAt       *time.Time     `json:"issued_at,omitempty"`
+	NotBefore      *time.Time     `json:"not_before,omitempty"`
+	ExpiresAt      *time.Time     `json:"expires_at,omitempty"`
+	Status         string         `json:"status"`
+	Signature      string         `json:"signature"`
+	SignatureKeyID string         `json:"signature_key_id,omitempty"`
+	SignatureError string         `json:"signature_error,omitempty"`
+}
+
+func NewTokenInspectCommand() *cobra.Command {
+	var env, jwksURL string
+	var idToken bool
+	var soon time.Duration
+
+	var inspectCmd = &cobra.Command{
+		Use:   "inspect [<credential_id>|-] [flags]",
+		Short: "Decode a JWT and check its expiry and signature",
+		Long: heredoc.Doc(`
+			Decode the header and claims of a JWT, show its exp, iat and nbf claims
+			as times and flag it when it is expired or expires soon. Everything
+			happens locally, tokens never need to be pasted into a website.
+
+			Given a credential, its cached access token is inspected, or its ID
+			token with --id-token. Given - or nothing, the token is read from
+			standard input, with or without a "Bearer " prefix.
+
+			The signature is verified when the environment of the credential, or
+			the one of --env, has a JWKS URL, or one is given with --jwks. It can be
+			an http(s) URL or a file.
+		`),
+		Example: heredoc.Doc(`
+			$ dwing token inspect dev/alice
+			$ dwing token inspect dev/alice --id-token -o json
+			$ pbpaste | dwing token inspect -e prod
+			$ echo "$TOKEN" | dwing token inspect - --jwks https://idp.example.com/.well-known/jwks.json
+		`),
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			envService, err := cmdutil.NewEnvironmentService(cmd)
+			if err != nil {
+				return err
+			}
+
+			var raw string
+			if len(args) == 0 || args[0] == "-" {
+				if idToken {
+					return errors.New("--id-token needs a credential")
+				}
+				data, err := io.ReadAll(io.LimitReader(cmd.InOrStdin(), 1<<20))
+				if err != nil {
+					return fmt.Errorf("failed to read token: %w", err)
+				}
+				raw = strings.TrimSpace(string(data))
+				if raw == "" {
+					return errors.New("no token on standard input")
+				}
+			} else {
+				id := args[0]
+
+				service, err := cmdutil.NewCredentialService(cmd)
+				if err != nil {
+					return err
+				}
+
+				cred, err := service.ResolveWithSecrets(id)
+				if err != nil {
+					if errors.Is(err, auth.ErrCredentialNotFound) {
+						return fmt.Errorf("credential '%s' not found", id)
+					}
+					return fmt.Errorf("failed to get credential: %w", err)
+				}
+
+				if cred.Token != nil {
+					raw = cred.Token.AccessToken
+					if idToken {
+						raw = cred.Token.IDToken
+					}
+				}
+				if raw == "" {
+					return fmt.Errorf("no cached token for '%s', run 'dwing token %s' first", id, id)
+				}
+
+				if env == "" {
+					env = cred.Environment
+				}
+			}
+
+			token, err := jwt.Decode(raw)
+			if err != nil {
+				return fmt.Errorf("the token is not a JWT: %w", err)
+			}
+
+			if jwksURL == "" && env != "" {
+				e, err := envService.GetEnvironment(env)
+				if err != nil && !errors.Is(err, environment.ErrEnvironmentNotFound) {
+					return fmt.Errorf("failed to get environment: %w", err)
+				}
+				jwksURL = e.Login.JWKSURL
+			}
+
+			result := inspect(token, time.Now(), soon)
+			if jwksURL != "" {
+				client := &http.Client{Timeout: 30 * time.Second}
+				keys, err := jwt.LoadJWKS(cmd.Context(), client, jwksURL)
+				if err != nil {
+					return err
+				}
+				result.verify(token, keys)
+			}
+
+			return printer.Print(result, func(w io.Writer) error {
+				return renderInspection(w, result, time.Now())
+			})
+		},
+	}
+
+	inspectCmd.Flags().StringVarP(&env, "env", "e", "", "Verify the signature with the JWKS of this environment")
+	inspectCmd.Flags().StringVar(&jwksURL, "jwks", "", "URL or file of the JWKS to verify the signature with")
+	inspectCmd.Flags().BoolVar(&idToken, "id-token", false, "Inspect the cached ID token instead of the access token")
+	inspectCmd.Flags().DurationVar(&soon, "soon", 5*time.Minute, "Flag tokens that expire within this duration")
+
+	_ = inspectCmd.RegisterFlagCompletionFunc("env", cmdutil.CompleteEnvironments)
+
+	return inspectCmd
+}
+
+func inspect(token *jwt.Token, now time.Time, soon time.Duration) inspection {
+	result := inspection{
+		Header:    token.Header,
+		Claims:    token.Claims,
+		Status:    token.Status(now, soon),
+		Signature: signatureUnverified,
+	}
+
+	for claim, dst := range map[string]**time.Time{"iat": &result.IssuedAt, "nbf": &result.NotBefore, "exp": &result.ExpiresAt} {
+		if t, ok := token.Time(claim); ok {
+			*dst = &t
+		}
+	}
+
+	return result
+}
+
+func (r *inspection) verify(token *jwt.Token, keys jwt.JWKS) {
+	kid, err := token.Verify(keys)
+	if err != nil {
+		r.Signature = signatureInvalid
+		r.SignatureKeyID = ""
+		r.SignatureError = err.Error()
+		return
+	}
+	r.Signature = signatureVerified
+	r.SignatureKeyID = kid
+}
+
+func renderInspection(w io.Writer, r inspection, now time.Time) error {
+	renderTime := func(label string, t *time.Time) {
+		if t == nil {
+			return
+		}
+		relative := now.Sub(*t).Round(time.Second).String() + " ago"
+		if t.After(now) {
+			relative = "in " + t.Sub(now).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%-11s %s (%s)\n", label, t.Local().Format(time.RFC1123), relative)
+	}
+
+	renderTime("Issued at:", r.IssuedAt)
+	renderTime("Not before:", r.NotBefore)
+	renderTime("Expires at:", r.ExpiresAt)
+
+	status := map[string]string{
+		jwt.StatusValid:        "valid",
+		jwt.StatusExpired:      "❌ expired",
+		jwt.StatusExpiringSoon: "⚠️  expires soon",
+		jwt.StatusNotYetValid:  "❌ not valid yet",
+	}[r.Status]
+	fmt.Fprintf(w, "%-11s %s\n", "Status:", status)
+
+	switch r.Signature {
+	case signatureVerified:
+		if r.SignatureKeyID == "" {
+			fmt.Fprintf(w, "%-11s verified\n", "Signature:")
+		} else {
+			fmt.Fprintf(w, "%-11s verified with key %s\n", "Signature:", r.SignatureKeyID)
+		}
+	case signatureInvalid:
+		fmt.Fprintf(w, "%-11s ❌ %s\n", "Signature:", r.SignatureError)
+	default:
+		fmt.Fprintf(w, "%-11s not verified, no JWKS configured\n", "Signature:")
+	}
+
+	for _, part := range []struct {
+		label string
+		value map[string]any
+	}{{"Header:", r.Header}, {"Claims:", r.Claims}} {
+		data, err := json.MarshalIndent(part.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "\n%s\n%s\n", part.label, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"jpellissari/dwing/internal/jwt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","kid":"k1"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","iat":1700000000,"exp":1700000120}`))
+	raw := input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(private, []byte(input)))
+
+	token, err := jwt.Decode(raw)
+	require.NoError(t, err)
+
+	now := time.Unix(1700000060, 0)
+	result := inspect(token, now, 5*time.Minute)
+
+	assert.Equal(t, jwt.StatusExpiringSoon, result.Status)
+	assert.Equal(t, signatureUnverified, result.Signature)
+	assert.Equal(t, time.Unix(1700000000, 0), *result.IssuedAt)
+	assert.Equal(t, time.Unix(1700000120, 0), *result.ExpiresAt)
+	assert.Nil(t, result.NotBefore)
+
+	result.verify(token, jwt.JWKS{Keys: []jwt.JWK{{Kty: "OKP", Kid: "k1", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)}}})
+	assert.Equal(t, signatureVerified, result.Signature)
+	assert.Equal(t, "k1", result.SignatureKeyID)
+
+	var buf bytes.Buffer
+	require.NoError(t, renderInspection(&buf, result, now))
+	assert.Contains(t, buf.String(), "(1m0s ago)\n")
+	assert.Contains(t, buf.String(), "(in 1m0s)\n")
+	assert.Contains(t, buf.String(), "Status:     ⚠️  expires soon\n")
+	assert.Contains(t, buf.String(), "Signature:  verified with key k1\n")
+	assert.Contains(t, buf.String(), "\"sub\": \"alice\"")
+	assert.NotContains(t, buf.String(), raw)
+
+	result.verify(token, jwt.JWKS{Keys: []jwt.JWK{{Kty: "OKP", Kid: "k2", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)}}})
+	assert.Equal(t, signatureInvalid, result.Signature)
+	assert.Equal(t, jwt.ErrNoKey.Error(), result.SignatureError)
+}
@@ -0,0 +1,97 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/cmdutil"
+	"jpellissari/dwing/internal/login"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+func NewTokenCmd() *cobra.Command {
+	var force bool
+
+	var tokenCmd = &cobra.Command{
+		Use:   "token <credential_id> [flags]",
+		Short: "Print an access token for a stored credential",
+		Long: heredoc.Docf(`
+			Print an access token for a stored credential, ready to be sent to an API.
+
+			The last token of each credential is cached, encrypted like its other
+			secrets, and reused until it is about to expire, going by the token
+			response or the exp claim of JWT access tokens. It is then renewed
+			with its refresh token when it has one, or by logging in again with the
+			credential. --force skips the cache and always logs in.
+
+			Login settings come from the credential's environment and can be
+			overridden with flags, as for 'dwing creds login'. Credentials of type
+			%[1]s use the client credentials grant unless another flow is set.
+		`, auth.TypeClientCredentials),
+		Example: heredoc.Doc(`
+			$ dwing token dev/alice
+			$ curl -H "Authorization: Bearer $(dwing token ci-bot)" https://api.dev.example.com/me
+			$ dwing token dev/alice --force -o json
+			$ dwing token inspect dev/alice
+		`),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cmdutil.CompleteCredentials,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			printer, err := cmdutil.NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := cmdutil.NewCredentialService(cmd)
+			if err != nil {
+				return err
+			}
+
+			cred, err := service.ResolveWithSecrets(id)
+			if err != nil {
+				if errors.Is(err, auth.ErrCredentialNotFound) {
+					return fmt.Errorf("credential '%s' not found", id)
+				}
+				return fmt.Errorf("failed to get credential: %w", err)
+			}
+
+			flow, err := cmdutil.NewLoginFlow(cmd, cred)
+			if err != nil {
+				return err
+			}
+
+			if force {
+				cred.Token = nil
+			}
+
+			token, fresh, err := login.Obtain(cmd.Context(), flow, cred, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to login: %w", err)
+			}
+
+			if fresh {
+				if err := service.CacheToken(cred.ID, &token); err != nil {
+					return err
+				}
+			}
+
+			return printer.Print(token, func(w io.Writer) error {
+				_, err := fmt.Fprintln(w, token.AccessToken)
+				return err
+			})
+		},
+	}
+
+	tokenCmd.AddCommand(NewTokenInspectCommand())
+
+	tokenCmd.Flags().BoolVar(&force, "force", false, "Log in again instead of using the cached token")
+	cmdutil.AddLoginFlags(tokenCmd)
+
+	return tokenCmd
+}
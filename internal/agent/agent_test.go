@@ -0,0 +1,157 @@
+package agent_test
+
+import (
+	"context"
+	"jpellissari/dwing/internal/agent"
+	"jpellissari/dwing/internal/auth"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startAgent(t *testing.T, idleTimeout time.Duration) (*agent.Client, string, <-chan error) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "dwing-agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	vaultPath := filepath.Join(dir, "credentials.json")
+	repo := auth.NewJSONRepository(vaultPath)
+
+	server := agent.NewServer(repo, vaultPath, socketPath, idleTimeout)
+	listener, err := server.Listen()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+
+	return agent.NewClient(socketPath), socketPath, done
+}
+
+func TestAgentRepository(t *testing.T) {
+	client, _, _ := startAgent(t, time.Minute)
+
+	require.NoError(t, client.Ping())
+
+	require.NoError(t, client.Add(auth.Credential{Environment: "env1", Username: "user1", Password: "pass1"}))
+	require.NoError(t, client.Add(auth.Credential{Environment: "env2", Username: "user2", Password: "pass2"}))
+
+	creds, err := client.GetAll()
+	require.NoError(t, err)
+	require.Len(t, creds, 2)
+
+	cred, err := client.GetById(creds[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pass1", cred.Password)
+
+	filtered, err := client.GetByEnv("env2")
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "user2", filtered[0].Username)
+
+	duplicate, err := client.CheckDuplicate(auth.Credential{Environment: "env1", Username: "user1"})
+	require.NoError(t, err)
+	assert.True(t, duplicate)
+
+	require.NoError(t, client.RemoveById(creds[0].ID))
+	assert.ErrorIs(t, client.RemoveById(creds[0].ID), auth.ErrCredentialNotFound)
+}
+
+func TestAgentSocketPermissions(t *testing.T) {
+	_, socketPath, _ := startAgent(t, time.Minute)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestAgentStatusAndStop(t *testing.T) {
+	client, socketPath, done := startAgent(t, time.Minute)
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), status.PID)
+	assert.Equal(t, time.Minute, status.IdleTimeout)
+	assert.True(t, status.Serves(filepath.Join(filepath.Dir(socketPath), "credentials.json")))
+	assert.False(t, status.Serves(filepath.Join(t.TempDir(), "credentials.json")))
+
+	require.NoError(t, client.Stop())
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err), "socket should be removed")
+	assert.ErrorIs(t, client.Ping(), agent.ErrNotRunning)
+}
+
+func TestAgentIdleTimeout(t *testing.T) {
+	_, _, done := startAgent(t, 100*time.Millisecond)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop after idle timeout")
+	}
+}
+
+func TestAgentIdleTimeoutIgnoresPing(t *testing.T) {
+	client, _, done := startAgent(t, 300*time.Millisecond)
+
+	timeout := time.After(5 * time.Second)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			return
+		case <-ticker.C:
+			_ = client.Ping()
+			_, _ = client.Status()
+		case <-timeout:
+			t.Fatal("pings kept the agent alive past its idle timeout")
+		}
+	}
+}
+
+func TestAgentUpdateConflict(t *testing.T) {
+	client, _, _ := startAgent(t, time.Minute)
+
+	require.NoError(t, client.Add(auth.Credential{ID: "a", Environment: "env1", Username: "user1", Password: "pass1"}))
+
+	first, err := client.GetById("a")
+	require.NoError(t, err)
+	second, err := client.GetById("a")
+	require.NoError(t, err)
+
+	first.Password = "first"
+	require.NoError(t, client.Update(first))
+
+	second.Password = "second"
+	err = client.Update(second)
+	require.ErrorIs(t, err, auth.ErrConflict)
+
+	var conflict *auth.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, second.Revision, conflict.Expected)
+
+	cred, err := client.GetById("a")
+	require.NoError(t, err)
+	assert.Equal(t, "first", cred.Password)
+}
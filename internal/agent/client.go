@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"net"
+	"time"
+)
+
+const dialTimeout = 2 * time.Second
+
+// Client is a CredentialRepository backed by a running agent.
+type Client struct {
+	socketPath string
+}
+
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+func (c *Client) call(req request) (response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return response{}, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer conn.Close()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return response{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return response{}, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return response{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return response{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.Error != "" {
+		if resp.ErrorCode == errCodeNotFound {
+			return resp, auth.ErrCredentialNotFound
+		}
+		if resp.Conflict != nil {
+			return resp, resp.Conflict
+		}
+		return resp, errors.New(resp.Error)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) Ping() error {
+	_, err := c.call(request{Method: methodPing})
+	return err
+}
+
+func (c *Client) Status() (Status, error) {
+	resp, err := c.call(request{Method: methodStatus})
+	if err != nil {
+		return Status{}, err
+	}
+	if resp.Status == nil {
+		return Status{}, errors.New("agent returned an empty status")
+	}
+	return *resp.Status, nil
+}
+
+func (c *Client) Stop() error {
+	_, err := c.call(request{Method: methodStop})
+	return err
+}
+
+func (c *Client) Add(cred auth.Credential) error {
+	_, err := c.call(request{Method: methodAdd, Credential: &cred})
+	return err
+}
+
+func (c *Client) GetAll() (auth.Credentials, error) {
+	resp, err := c.call(request{Method: methodGetAll})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Credentials == nil {
+		return auth.Credentials{}, nil
+	}
+	return resp.credentials(), nil
+}
+
+func (c *Client) ListMetadata() (auth.Credentials, error) {
+	resp, err := c.call(request{Method: methodListMetadata})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Credentials == nil {
+		return auth.Credentials{}, nil
+	}
+	return resp.Credentials, nil
+}
+
+func (c *Client) CheckDuplicate(cred auth.Credential) (bool, error) {
+	resp, err := c.call(request{Method: methodCheckDuplicate, Credential: &cred})
+	if err != nil {
+		return false, err
+	}
+	return resp.Duplicate, nil
+}
+
+func (c *Client) GetById(id string) (auth.Credential, error) {
+	resp, err := c.call(request{Method: methodGetById, ID: id})
+	if err != nil {
+		return auth.Credential{}, err
+	}
+	if resp.Credential == nil {
+		return auth.Credential{}, auth.ErrCredentialNotFound
+	}
+	cred := *resp.Credential
+	cred.Revision = resp.Revision
+	return cred, nil
+}
+
+func (c *Client) GetByEnv(env string) (auth.Credentials, error) {
+	resp, err := c.call(request{Method: methodGetByEnv, Env: env})
+	if err != nil {
+		return nil, err
+	}
+	return resp.credentials(), nil
+}
+
+func (c *Client) RemoveById(id string) error {
+	_, err := c.call(request{Method: methodRemoveById, ID: id})
+	return err
+}
+
+func (c *Client) Update(cred auth.Credential) error {
+	_, err := c.call(request{Method: methodUpdate, Credential: &cred, Revision: cred.Revision})
+	return err
+}
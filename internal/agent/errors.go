@@ -0,0 +1,11 @@
+package agent
+
+import "errors"
+
+var (
+	ErrNotRunning       = errors.New("agent is not running")
+	ErrPeerNotAllowed   = errors.New("peer is not allowed to talk to the agent")
+	ErrPeerCredentials  = errors.New("peer credentials are not supported on this platform")
+	ErrUnknownMethod    = errors.New("unknown agent method")
+	ErrMalformedRequest = errors.New("malformed agent request")
+)
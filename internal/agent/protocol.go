@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"path/filepath"
+	"time"
+)
+
+const (
+	methodPing           = "ping"
+	methodStatus         = "status"
+	methodStop           = "stop"
+	methodAdd            = "add"
+	methodGetAll         = "get_all"
+	methodListMetadata   = "list_metadata"
+	methodCheckDuplicate = "check_duplicate"
+	methodGetById        = "get_by_id"
+	methodGetByEnv       = "get_by_env"
+	methodRemoveById     = "remove_by_id"
+	methodUpdate         = "update"
+)
+
+const (
+	errCodeNotFound = "not_found"
+)
+
+type request struct {
+	Method     string           `json:"method"`
+	ID         string           `json:"id,omitempty"`
+	Env        string           `json:"env,omitempty"`
+	Credential *auth.Credential `json:"credential,omitempty"`
+	// Revision is the store revision the credential of an update was read
+	// at, which the credential itself does not carry over the wire.
+	Revision int64 `json:"revision,omitempty"`
+}
+
+type response struct {
+	Credential  *auth.Credential    `json:"credential,omitempty"`
+	Credentials auth.Credentials    `json:"credentials,omitempty"`
+	Revision    int64               `json:"revision,omitempty"`
+	Duplicate   bool                `json:"duplicate,omitempty"`
+	Status      *Status             `json:"status,omitempty"`
+	Conflict    *auth.ConflictError `json:"conflict,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	ErrorCode   string              `json:"error_code,omitempty"`
+}
+
+// credentials returns the credentials of the response with the revision
+// they were read at.
+func (r response) credentials() auth.Credentials {
+	for i := range r.Credentials {
+		r.Credentials[i].Revision = r.Revision
+	}
+	return r.Credentials
+}
+
+type Status struct {
+	PID         int           `json:"pid"`
+	VaultPath   string        `json:"vault_path"`
+	StartedAt   time.Time     `json:"started_at"`
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+}
+
+// Serves reports whether the agent serves the vault at vaultPath.
+func (s Status) Serves(vaultPath string) bool {
+	return s.VaultPath == filepath.Clean(vaultPath)
+}
@@ -0,0 +1,265 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Server keeps an unlocked credential repository in memory and serves it to
+// clients of the same user over a Unix socket. It shuts itself down after
+// IdleTimeout without requests.
+type Server struct {
+	repo        auth.CredentialRepository
+	vaultPath   string
+	socketPath  string
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+	lastUsed  time.Time
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewServer returns a server for repo, the vault at vaultPath. The path is
+// reported in the status so clients can tell which vault the agent serves.
+func NewServer(repo auth.CredentialRepository, vaultPath, socketPath string, idleTimeout time.Duration) *Server {
+	return &Server{
+		repo:        repo,
+		vaultPath:   filepath.Clean(vaultPath),
+		socketPath:  socketPath,
+		idleTimeout: idleTimeout,
+		stop:        make(chan struct{}),
+	}
+}
+
+func (s *Server) Listen() (*net.UnixListener, error) {
+	dir := filepath.Dir(s.socketPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if err := os.Remove(s.socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: s.socketPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on socket: %w", err)
+	}
+
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// Serve accepts connections until the context is cancelled, a client asks
+// the agent to stop, or the idle timeout expires.
+func (s *Server) Serve(ctx context.Context, listener *net.UnixListener) error {
+	s.mu.Lock()
+	s.startedAt = time.Now()
+	s.lastUsed = s.startedAt
+	s.mu.Unlock()
+
+	defer os.Remove(s.socketPath)
+
+	go func() {
+		ticker := time.NewTicker(s.idleCheckInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.shutdown()
+			case <-s.stop:
+				listener.Close()
+				return
+			case <-ticker.C:
+				if s.idle() {
+					s.shutdown()
+				}
+			}
+		}
+	}()
+
+	for {
+		conn, err := listener.AcceptUnix()
+		if err != nil {
+			select {
+			case <-s.stop:
+				return nil
+			default:
+				return fmt.Errorf("failed to accept connection: %w", err)
+			}
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) idleCheckInterval() time.Duration {
+	interval := s.idleTimeout / 10
+	if interval <= 0 || interval > time.Second {
+		return time.Second
+	}
+	return interval
+}
+
+func (s *Server) idle() bool {
+	if s.idleTimeout <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return time.Since(s.lastUsed) >= s.idleTimeout
+}
+
+func (s *Server) shutdown() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *Server) handle(conn *net.UnixConn) {
+	defer conn.Close()
+
+	uid, err := peerUID(conn)
+	if err != nil || uid != os.Getuid() {
+		_ = json.NewEncoder(conn).Encode(response{Error: ErrPeerNotAllowed.Error()})
+		return
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var req request
+		var resp response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = errorResponse(ErrMalformedRequest)
+		} else {
+			resp = s.dispatch(req)
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+
+		if req.Method == methodStop {
+			s.shutdown()
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Only credential requests keep the agent alive. Commands ping it on
+	// every run and shell completion, which must not defer the idle timeout.
+	switch req.Method {
+	case methodPing, methodStatus, methodStop:
+	default:
+		s.lastUsed = time.Now()
+	}
+
+	switch req.Method {
+	case methodPing, methodStop:
+		return response{}
+	case methodStatus:
+		return response{Status: &Status{
+			PID:         os.Getpid(),
+			VaultPath:   s.vaultPath,
+			StartedAt:   s.startedAt,
+			IdleTimeout: s.idleTimeout,
+			ExpiresAt:   s.lastUsed.Add(s.idleTimeout),
+		}}
+	case methodAdd:
+		if req.Credential == nil {
+			return errorResponse(ErrMalformedRequest)
+		}
+		return errorResponse(s.repo.Add(*req.Credential))
+	case methodGetAll:
+		creds, err := s.repo.GetAll()
+		if err != nil {
+			return errorResponse(err)
+		}
+		return credentialsResponse(creds)
+	case methodListMetadata:
+		creds, err := s.repo.ListMetadata()
+		if err != nil {
+			return errorResponse(err)
+		}
+		return response{Credentials: creds}
+	case methodCheckDuplicate:
+		if req.Credential == nil {
+			return errorResponse(ErrMalformedRequest)
+		}
+		duplicate, err := s.repo.CheckDuplicate(*req.Credential)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return response{Duplicate: duplicate}
+	case methodGetById:
+		cred, err := s.repo.GetById(req.ID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return response{Credential: &cred, Revision: cred.Revision}
+	case methodGetByEnv:
+		creds, err := s.repo.GetByEnv(req.Env)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return credentialsResponse(creds)
+	case methodRemoveById:
+		return errorResponse(s.repo.RemoveById(req.ID))
+	case methodUpdate:
+		if req.Credential == nil {
+			return errorResponse(ErrMalformedRequest)
+		}
+		cred := *req.Credential
+		cred.Revision = req.Revision
+		return errorResponse(s.repo.Update(cred))
+	default:
+		return errorResponse(fmt.Errorf("%w: %s", ErrUnknownMethod, req.Method))
+	}
+}
+
+// credentialsResponse returns creds with the revision they were read at.
+// They all come from the same read, so they share it.
+func credentialsResponse(creds auth.Credentials) response {
+	resp := response{Credentials: creds}
+	if len(creds) > 0 {
+		resp.Revision = creds[0].Revision
+	}
+	return resp
+}
+
+func errorResponse(err error) response {
+	if err == nil {
+		return response{}
+	}
+
+	resp := response{Error: err.Error()}
+	if errors.Is(err, auth.ErrCredentialNotFound) {
+		resp.ErrorCode = errCodeNotFound
+	}
+	errors.As(err, &resp.Conflict)
+
+	return resp
+}
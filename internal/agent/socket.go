@@ -0,0 +1,23 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultSocketPath returns the agent socket location. It lives in
+// $XDG_RUNTIME_DIR when available, since that directory is private to the
+// user and cleaned up on logout, and falls back to ~/.dwing otherwise.
+func DefaultSocketPath() (string, error) {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "dwing", "agent.sock"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".dwing", "agent.sock"), nil
+}
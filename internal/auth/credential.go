@@ -2,27 +2,211 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/otp"
+	"maps"
+	"strings"
 )
 
 type Credential struct {
 	ID          string `json:"id"`
 	Environment string `json:"environment"`
 	Username    string `json:"username"`
-	Password    string `json:"password"`
+	Password    string `json:"password,omitempty"`
 	Nickname    string `json:"nickname"`
+	// OTPSeed is an optional TOTP seed, either base32 or an otpauth:// URI.
+	OTPSeed string `json:"otp_seed,omitempty"`
+	// Type names the schema of the credential, see Types. Empty means
+	// TypePassword.
+	Type string `json:"type,omitempty"`
+	// Fields holds the values of schema fields that have no dedicated
+	// struct field, such as the host of a database credential.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Token caches the last OAuth2 token obtained with the credential. It is
+	// a secret, sealed with the others in a vault.
+	Token *Token `json:"token,omitempty"`
+	// Revision is the revision of the store the credential was read at.
+	// Update refuses to write it back once the store has changed since.
+	Revision int64 `json:"-"`
+}
+
+// SameEnvironment reports whether two environment names are the same.
+// References match environments case insensitively, so uniqueness checks
+// must too, or two credentials could never be told apart.
+func SameEnvironment(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// Kind returns the type of the credential, defaulting to TypePassword.
+func (c Credential) Kind() string {
+	if c.Type == "" {
+		return TypePassword
+	}
+	return c.Type
+}
+
+// Get returns the value of a schema field.
+func (c Credential) Get(field string) string {
+	switch field {
+	case FieldUsername:
+		return c.Username
+	case FieldPassword:
+		return c.Password
+	case FieldOTPSeed:
+		return c.OTPSeed
+	default:
+		return c.Fields[field]
+	}
+}
+
+// Set changes the value of a schema field. Setting an extra field to an
+// empty value removes it.
+func (c *Credential) Set(field, value string) {
+	switch field {
+	case FieldUsername:
+		c.Username = value
+	case FieldPassword:
+		c.Password = value
+	case FieldOTPSeed:
+		c.OTPSeed = value
+	default:
+		if value == "" {
+			delete(c.Fields, field)
+			return
+		}
+		if c.Fields == nil {
+			c.Fields = map[string]string{}
+		}
+		c.Fields[field] = value
+	}
 }
 
 func (c *Credential) Validate() error {
 	if c.Environment == "" {
 		return errors.New("environment is required")
 	}
-	if c.Username == "" {
-		return errors.New("username is required")
+
+	t, err := LookupType(c.Type)
+	if err != nil {
+		return err
+	}
+	for _, f := range t.Fields {
+		if f.Required && c.Get(f.Name) == "" {
+			return fmt.Errorf("%s is required", f.Name)
+		}
+	}
+	for name := range c.Fields {
+		if _, ok := t.Field(name); !ok {
+			return fmt.Errorf("unknown field %q for %s credentials", name, t.Name)
+		}
+	}
+	if _, ok := t.Field(FieldOTPSeed); !ok && c.OTPSeed != "" {
+		return fmt.Errorf("%s credentials do not support an OTP seed", t.Name)
+	}
+
+	if strings.Contains(c.Nickname, "/") {
+		return errors.New("nickname cannot contain '/'")
 	}
-	if c.Password == "" {
-		return errors.New("password is required")
+	if c.OTPSeed != "" {
+		if _, err := otp.Parse(c.OTPSeed); err != nil {
+			return err
+		}
+	}
+	if t.validate != nil {
+		return t.validate(*c)
 	}
 	return nil
 }
 
+// isSecretField reports whether an extra field holds a secret. Fields of an
+// unknown type are treated as secrets.
+func (c Credential) isSecretField(name string) bool {
+	t, err := LookupType(c.Type)
+	if err != nil {
+		return true
+	}
+	f, ok := t.Field(name)
+	return !ok || f.Secret
+}
+
+// secretFields returns the extra fields of the credential that hold secrets.
+func (c Credential) secretFields() map[string]string {
+	var secrets map[string]string
+	for name, value := range c.Fields {
+		if !c.isSecretField(name) {
+			continue
+		}
+		if secrets == nil {
+			secrets = map[string]string{}
+		}
+		secrets[name] = value
+	}
+	return secrets
+}
+
+// RedactedSecret replaces secrets in output that should not reveal them.
+const RedactedSecret = "********"
+
+// Redacted returns a copy of the credential with its secrets masked.
+func (c Credential) Redacted() Credential {
+	if c.Password != "" {
+		c.Password = RedactedSecret
+	}
+	if c.OTPSeed != "" {
+		c.OTPSeed = RedactedSecret
+	}
+	c.Fields = maps.Clone(c.Fields)
+	for name := range c.secretFields() {
+		c.Fields[name] = RedactedSecret
+	}
+	if c.Token != nil {
+		token := c.Token.Redacted()
+		c.Token = &token
+	}
+	return c
+}
+
+// Metadata returns a copy of the credential without its secrets.
+func (c Credential) Metadata() Credential {
+	c.Password = ""
+	c.OTPSeed = ""
+	c.Token = nil
+	secrets := c.secretFields()
+	c.Fields = maps.Clone(c.Fields)
+	for name := range secrets {
+		delete(c.Fields, name)
+	}
+	if len(c.Fields) == 0 {
+		c.Fields = nil
+	}
+	return c
+}
+
+// MergeSecrets copies the secrets that from has into the credential: its
+// password, OTP seed and the fields that are secret for the credential's
+// type. Everything else, such as the nickname, type and other fields, is
+// kept.
+func (c *Credential) MergeSecrets(from Credential) {
+	if from.Password != "" {
+		c.Password = from.Password
+	}
+	if from.OTPSeed != "" {
+		c.OTPSeed = from.OTPSeed
+	}
+	c.Fields = maps.Clone(c.Fields)
+	for name, value := range from.Fields {
+		if value != "" && c.isSecretField(name) {
+			c.Set(name, value)
+		}
+	}
+}
+
 type Credentials []Credential
+
+func (c Credentials) Redacted() Credentials {
+	redacted := make(Credentials, len(c))
+	for i, cred := range c {
+		redacted[i] = cred.Redacted()
+	}
+	return redacted
+}
@@ -1,11 +1,13 @@
 package auth
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"jpellissari/dwing/internal/fsutil"
 	"os"
-	"path/filepath"
+	"sync"
 
 	"github.com/google/uuid"
 )
@@ -13,33 +15,84 @@ import (
 type CredentialRepository interface {
 	Add(cred Credential) error
 	GetAll() (Credentials, error)
+	// ListMetadata returns every credential without its secrets, so it never
+	// needs to unlock an encrypted store.
+	ListMetadata() (Credentials, error)
 	CheckDuplicate(cred Credential) (bool, error)
 	GetById(id string) (Credential, error)
 	GetByEnv(env string) (Credentials, error)
 	RemoveById(id string) error
+	Update(cred Credential) error
 }
 
+// storedCredential is a credential as written to disk. In a vault its
+// secrets are moved into Secret, sealed with the vault key.
+type storedCredential struct {
+	Credential
+	Secret []byte `json:"secret,omitempty"`
+}
+
+// credentialsFile is the on-disk document. Revision is bumped on every write,
+// so a writer can tell whether someone else changed the file since it was
+// read. Vault is only set for encrypted stores.
+type credentialsFile struct {
+	Revision    int64              `json:"revision"`
+	Vault       *vaultParams       `json:"vault,omitempty"`
+	Credentials []storedCredential `json:"credentials"`
+
+	// legacy marks a version 1 vault that was decrypted as a whole.
+	legacy bool
+	// plaintext marks an existing file without a vault section.
+	plaintext bool
+}
+
+// JSONRepository stores credentials in a single JSON file. Mutations take an
+// advisory lock on a sibling ".lock" file and replace the file atomically, so
+// concurrent dwing processes never lose updates or leave a truncated file.
 type JSONRepository struct {
 	filePath string
+	keyring  *vaultKeyring
+
+	mu       sync.Mutex
+	revision int64
+	loaded   bool
 }
 
 func NewJSONRepository(filePath string) *JSONRepository {
 	return &JSONRepository{filePath: filePath}
 }
 
+// GetById returns the credential with its secrets. In a vault only that
+// credential's secrets are opened.
 func (r *JSONRepository) GetById(id string) (Credential, error) {
-	creds, err := r.GetAll()
+	file, err := r.read()
 	if err != nil {
 		return Credential{}, err
 	}
 
-	for _, c := range creds {
-		if c.ID == id {
-			return c, nil
+	for _, record := range file.Credentials {
+		if record.ID != id {
+			continue
+		}
+
+		var key []byte
+		if file.Vault != nil {
+			key, err = r.keyring.unlock(file.Vault)
+			if err != nil {
+				return Credential{}, err
+			}
+		}
+
+		cred, err := openCredential(key, record)
+		if err != nil {
+			return Credential{}, err
 		}
+		cred.Revision = file.Revision
+
+		return cred, nil
 	}
 
-	return Credential{}, fmt.Errorf("credential with ID '%s' not found", id)
+	return Credential{}, ErrCredentialNotFound
 }
 
 func (r *JSONRepository) GetByEnv(env string) (Credentials, error) {
@@ -59,87 +112,339 @@ func (r *JSONRepository) GetByEnv(env string) (Credentials, error) {
 }
 
 func (r *JSONRepository) RemoveById(id string) error {
-	creds, err := r.GetAll()
+	return r.mutate(anyRevision, func(records []storedCredential) ([]storedCredential, error) {
+		for i, c := range records {
+			if c.ID == id {
+				return append(records[:i], records[i+1:]...), nil
+			}
+		}
+
+		return nil, ErrCredentialNotFound
+	})
+}
+
+// Update replaces the stored credential with cred. It fails with a
+// *ConflictError when the store changed since cred was read, so the changes
+// of another writer are never silently overwritten.
+func (r *JSONRepository) Update(cred Credential) error {
+	return r.mutate(cred.Revision, func(records []storedCredential) ([]storedCredential, error) {
+		for i, c := range records {
+			if c.ID == cred.ID {
+				records[i] = storedCredential{Credential: cred}
+				return records, nil
+			}
+		}
+
+		return nil, ErrCredentialNotFound
+	})
+}
+
+func (r *JSONRepository) Add(cred Credential) error {
+	return r.mutate(anyRevision, func(records []storedCredential) ([]storedCredential, error) {
+		if cred.ID == "" {
+			cred.ID = uuid.New().String()
+		}
+
+		return append(records, storedCredential{Credential: cred}), nil
+	})
+}
+
+func (r *JSONRepository) CheckDuplicate(cred Credential) (bool, error) {
+	creds, err := r.ListMetadata()
 	if err != nil {
-		return err
+		return false, fmt.Errorf("failed to check duplicates: %w", err)
 	}
 
-	var updatedCreds Credentials
-	for i, c := range creds {
-		if c.ID == id {
-			updatedCreds = append(creds[:i], creds[i+1:]...)
-			return r.Save(updatedCreds)
+	for _, existingCred := range creds {
+		if cred.ID != "" && existingCred.ID == cred.ID {
+			continue
+		}
+		if SameEnvironment(existingCred.Environment, cred.Environment) && existingCred.Username == cred.Username {
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
-	return ErrCredentialNotFound
+func (r *JSONRepository) GetAll() (Credentials, error) {
+	file, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	if file.Vault != nil {
+		key, err = r.keyring.unlock(file.Vault)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	creds := make(Credentials, 0, len(file.Credentials))
+	for _, record := range file.Credentials {
+		cred, err := openCredential(key, record)
+		if err != nil {
+			return nil, err
+		}
+		cred.Revision = file.Revision
+		creds = append(creds, cred)
+	}
+
+	return creds, nil
 }
 
-func (r *JSONRepository) Add(cred Credential) error {
-	creds, err := r.GetAll()
+func (r *JSONRepository) ListMetadata() (Credentials, error) {
+	file, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := make(Credentials, 0, len(file.Credentials))
+	for _, record := range file.Credentials {
+		creds = append(creds, record.Credential.Metadata())
+	}
+
+	return creds, nil
+}
+
+// Save replaces every stored credential with c. If this repository has read
+// the file before, Save fails with a *ConflictError when another writer
+// changed it in the meantime. Saving through an EncryptedRepository turns a
+// plaintext store into a vault.
+func (r *JSONRepository) Save(c Credentials) error {
+	expected, loaded := r.Revision()
+	if !loaded {
+		expected = anyRevision
+	}
+
+	return r.SaveAt(c, expected)
+}
+
+// SaveAt is like Save, but fails with a *ConflictError unless the file is
+// still at revision. It is used when c was read through another repository,
+// such as a plaintext store read before it is encrypted.
+func (r *JSONRepository) SaveAt(c Credentials, revision int64) error {
+	unlock, err := r.lock()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	cred.ID = uuid.New().String()
-	creds = append(creds, cred)
+	current, err := r.load()
+	if err != nil {
+		return err
+	}
 
-	if err := r.Save(creds); err != nil {
-		return fmt.Errorf("failed to save credentials: %w", err)
+	if revision != anyRevision && current.Revision != revision {
+		return &ConflictError{Expected: revision, Actual: current.Revision}
 	}
 
-	return nil
+	file := credentialsFile{Revision: current.Revision + 1, Vault: current.Vault}
+	for _, cred := range c {
+		file.Credentials = append(file.Credentials, storedCredential{Credential: cred})
+	}
+
+	return r.write(file)
 }
 
-func (r *JSONRepository) CheckDuplicate(cred Credential) (bool, error) {
-	creds, err := r.GetAll()
+// Revision returns the revision of the file when this repository last read
+// or wrote it, and false when it has not done either yet.
+func (r *JSONRepository) Revision() (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.revision, r.loaded
+}
+
+// read loads the file and records its revision.
+func (r *JSONRepository) read() (credentialsFile, error) {
+	file, err := r.load()
 	if err != nil {
-		return false, fmt.Errorf("failed to check duplicates: %w", err)
+		return credentialsFile{}, err
 	}
 
-	for _, existingCred := range creds {
-		if existingCred.Environment == cred.Environment && existingCred.Username == cred.Username {
-			return true, nil
-		}
+	if err := r.checkFormat(file); err != nil {
+		return credentialsFile{}, err
 	}
-	return false, nil
+
+	r.mu.Lock()
+	r.revision = file.Revision
+	r.loaded = true
+	r.mu.Unlock()
+
+	return file, nil
 }
 
-func (r *JSONRepository) GetAll() (Credentials, error) {
-	if _, err := os.Stat(r.filePath); errors.Is(err, os.ErrNotExist) {
-		return Credentials{}, nil
+// anyRevision makes mutate apply its change whatever the current revision.
+const anyRevision = -1
+
+// mutate applies fn to the current credentials while holding the file lock,
+// so the read-modify-write cycle is atomic across processes. Unless expected
+// is anyRevision, it fails with a *ConflictError when the file is no longer
+// at that revision.
+func (r *JSONRepository) mutate(expected int64, fn func([]storedCredential) ([]storedCredential, error)) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	if err := r.checkFormat(current); err != nil {
+		return err
+	}
+
+	if expected != anyRevision && current.Revision != expected {
+		return &ConflictError{Expected: expected, Actual: current.Revision}
+	}
+
+	records, err := fn(current.Credentials)
+	if err != nil {
+		return err
+	}
+
+	file := credentialsFile{Revision: current.Revision + 1, Vault: current.Vault, Credentials: records}
+	if err := r.write(file); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+
+	return nil
+}
+
+// checkFormat makes sure a plaintext repository does not read a vault and a
+// vault repository does not read a plaintext store.
+func (r *JSONRepository) checkFormat(file credentialsFile) error {
+	encrypted := file.Vault != nil || file.legacy
+
+	if r.keyring == nil && encrypted {
+		return ErrVaultLocked
+	}
+	if r.keyring != nil && file.plaintext {
+		return ErrNotAVault
 	}
 
+	return nil
+}
+
+func (r *JSONRepository) lock() (func() error, error) {
+	return fsutil.LockDir(r.filePath)
+}
+
+func (r *JSONRepository) load() (credentialsFile, error) {
 	data, err := os.ReadFile(r.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return credentialsFile{Credentials: []storedCredential{}}, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return credentialsFile{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	if len(data) == 0 {
-		return Credentials{}, nil
+		return credentialsFile{Credentials: []storedCredential{}}, nil
+	}
+
+	legacy := isLegacyVault(data)
+	if legacy {
+		if r.keyring == nil {
+			return credentialsFile{}, ErrVaultLocked
+		}
+
+		data, err = r.keyring.openLegacyVault(data)
+		if err != nil {
+			return credentialsFile{}, err
+		}
 	}
 
-	var c Credentials
-	if err := json.Unmarshal(data, &c); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	var file credentialsFile
+
+	// Files written before revisions were introduced hold a bare array.
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &file.Credentials); err != nil {
+			return credentialsFile{}, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &file); err != nil {
+		return credentialsFile{}, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
 
-	return c, nil
+	if file.Credentials == nil {
+		file.Credentials = []storedCredential{}
+	}
+
+	file.legacy = legacy
+	file.plaintext = !legacy && file.Vault == nil
+
+	return file, nil
 }
 
-func (r *JSONRepository) Save(c Credentials) error {
-	dir := filepath.Dir(r.filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+// write seals the secrets that are not sealed yet, replaces the credentials
+// file atomically and records the revision it wrote.
+func (r *JSONRepository) write(file credentialsFile) error {
+	if file.Credentials == nil {
+		file.Credentials = []storedCredential{}
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	if r.keyring != nil {
+		if err := r.seal(&file); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	if err := os.WriteFile(r.filePath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	if err := fsutil.WriteFileAtomic(r.filePath, data, 0600); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.revision = file.Revision
+	r.loaded = true
+	r.mu.Unlock()
+
+	return nil
+}
+
+// seal encrypts the secrets of new and changed credentials. The key is only
+// derived when there is something to seal, so removing a credential from a
+// vault does not need the passphrase.
+func (r *JSONRepository) seal(file *credentialsFile) error {
+	var key []byte
+	for i, record := range file.Credentials {
+		if record.Secret != nil {
+			continue
+		}
+
+		if key == nil {
+			var err error
+			if file.Vault == nil {
+				file.Vault, key, err = r.keyring.create()
+			} else {
+				key, err = r.keyring.unlock(file.Vault)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		sealed, err := sealCredential(key, record.Credential)
+		if err != nil {
+			return err
+		}
+		file.Credentials[i] = sealed
+	}
+
+	if file.Vault == nil {
+		var err error
+		file.Vault, _, err = r.keyring.create()
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,162 @@
+package auth_test
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	helperPathEnv   = "DWING_TEST_HELPER_CREDENTIALS_PATH"
+	helperPrefixEnv = "DWING_TEST_HELPER_PREFIX"
+	helperCountEnv  = "DWING_TEST_HELPER_COUNT"
+)
+
+// TestConcurrentAddHelperProcess is not a real test: it is re-executed as a
+// child process by TestConcurrentAdd to add credentials from another process.
+func TestConcurrentAddHelperProcess(t *testing.T) {
+	filePath := os.Getenv(helperPathEnv)
+	if filePath == "" {
+		t.Skip("helper process")
+	}
+
+	count, err := strconv.Atoi(os.Getenv(helperCountEnv))
+	require.NoError(t, err)
+
+	addMany(t, auth.NewJSONRepository(filePath), os.Getenv(helperPrefixEnv), count)
+}
+
+func addMany(t *testing.T, repo *auth.JSONRepository, prefix string, count int) {
+	for i := 0; i < count; i++ {
+		err := repo.Add(auth.Credential{
+			Environment: "env1",
+			Username:    fmt.Sprintf("%s-%d", prefix, i),
+			Password:    "pass",
+		})
+		assert.NoError(t, err)
+	}
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	const (
+		goroutines   = 16
+		processes    = 4
+		addsPerActor = 10
+	)
+
+	filePath := filepath.Join(t.TempDir(), "credentials.json")
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			addMany(t, auth.NewJSONRepository(filePath), fmt.Sprintf("goroutine%d", g), addsPerActor)
+		}(g)
+	}
+
+	var cmds []*exec.Cmd
+	for p := 0; p < processes; p++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentAddHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			helperPathEnv+"="+filePath,
+			helperPrefixEnv+"="+fmt.Sprintf("process%d", p),
+			helperCountEnv+"="+strconv.Itoa(addsPerActor),
+		)
+		require.NoError(t, cmd.Start())
+		cmds = append(cmds, cmd)
+	}
+
+	wg.Wait()
+	for _, cmd := range cmds {
+		require.NoError(t, cmd.Wait())
+	}
+
+	creds, err := auth.NewJSONRepository(filePath).GetAll()
+	require.NoError(t, err)
+	assert.Len(t, creds, (goroutines+processes)*addsPerActor)
+
+	ids := map[string]bool{}
+	for _, c := range creds {
+		ids[c.ID] = true
+	}
+	assert.Len(t, ids, len(creds), "every credential should have a unique ID")
+
+	leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(filePath), ".*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "temporary files should be cleaned up")
+}
+
+func TestSaveConflict(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "credentials.json")
+
+	first := auth.NewJSONRepository(filePath)
+	second := auth.NewJSONRepository(filePath)
+
+	require.NoError(t, first.Add(auth.Credential{Environment: "env1", Username: "user1", Password: "pass1"}))
+
+	firstView, err := first.GetAll()
+	require.NoError(t, err)
+	secondView, err := second.GetAll()
+	require.NoError(t, err)
+
+	require.NoError(t, second.Save(append(secondView, auth.Credential{Environment: "env1", Username: "user2", Password: "pass2"})))
+
+	err = first.Save(append(firstView, auth.Credential{Environment: "env1", Username: "user3", Password: "pass3"}))
+	require.ErrorIs(t, err, auth.ErrConflict)
+
+	var conflict *auth.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, int64(1), conflict.Expected)
+	assert.Equal(t, int64(2), conflict.Actual)
+
+	creds, err := first.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, creds, 2, "the conflicting save must not be applied")
+
+	require.NoError(t, first.Save(append(creds, auth.Credential{Environment: "env1", Username: "user3", Password: "pass3"})))
+}
+
+func TestSaveAtConflict(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "credentials.json")
+
+	plain := auth.NewJSONRepository(filePath)
+	require.NoError(t, plain.Add(auth.Credential{Environment: "env1", Username: "user1", Password: "pass1"}))
+
+	creds, err := plain.GetAll()
+	require.NoError(t, err)
+	revision, loaded := plain.Revision()
+	require.True(t, loaded)
+
+	require.NoError(t, auth.NewJSONRepository(filePath).Add(auth.Credential{Environment: "env1", Username: "user2", Password: "pass2"}))
+
+	vault := auth.NewEncryptedRepository(filePath, "correct horse")
+	err = vault.SaveAt(creds, revision)
+	require.ErrorIs(t, err, auth.ErrConflict)
+
+	var conflict *auth.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, revision, conflict.Expected)
+	assert.Equal(t, revision+1, conflict.Actual)
+
+	isVault, err := auth.IsVault(filePath)
+	require.NoError(t, err)
+	assert.False(t, isVault, "the conflicting save must not encrypt the store")
+
+	creds, err = plain.GetAll()
+	require.NoError(t, err)
+	revision, _ = plain.Revision()
+	require.NoError(t, vault.SaveAt(creds, revision))
+
+	got, err := vault.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
@@ -11,6 +11,11 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+type credentialsFile struct {
+	Revision    int64            `json:"revision"`
+	Credentials auth.Credentials `json:"credentials"`
+}
+
 func TestLoad(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -72,6 +77,17 @@ func TestLoad(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "revisioned document",
+			setupFile: func(t *testing.T, filePath string) {
+				err := os.WriteFile(filePath, []byte(`{"revision": 4, "credentials": [{"username": "user1", "password": "pass1", "environment": "env1"}]}`), 0644)
+				require.NoError(t, err)
+			},
+			wantCreds: auth.Credentials{
+				{Username: "user1", Password: "pass1", Environment: "env1", Revision: 4},
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid JSON returns error",
 			setupFile: func(t *testing.T, filePath string) {
@@ -157,14 +173,14 @@ func TestSave(t *testing.T) {
 				data, err := os.ReadFile(filePath)
 				require.NoError(t, err)
 
-				var loaded auth.Credentials
+				var loaded credentialsFile
 				err = json.Unmarshal(data, &loaded)
 				require.NoError(t, err)
-				assert.Len(t, loaded, 1)
+				assert.Len(t, loaded.Credentials, 1)
 			},
 		},
 		{
-			name:  "empty credentials creates empty array",
+			name:  "empty credentials creates empty list",
 			creds: auth.Credentials{},
 			setupDir: func(t *testing.T, tmpDir string) string {
 				return filepath.Join(tmpDir, "credentials.json")
@@ -173,7 +189,7 @@ func TestSave(t *testing.T) {
 			verifyFile: func(t *testing.T, filePath string) {
 				data, err := os.ReadFile(filePath)
 				require.NoError(t, err)
-				assert.Equal(t, "[]", string(data))
+				assert.JSONEq(t, `{"revision": 1, "credentials": []}`, string(data))
 			},
 		},
 		{
@@ -237,10 +253,10 @@ func TestSave(t *testing.T) {
 			verifyFile: func(t *testing.T, filePath string) {
 				data, err := os.ReadFile(filePath)
 				require.NoError(t, err)
-				var loaded auth.Credentials
+				var loaded credentialsFile
 				err = json.Unmarshal(data, &loaded)
 				require.NoError(t, err)
-				assert.Len(t, loaded, 3)
+				assert.Len(t, loaded.Credentials, 3)
 			},
 		},
 	}
@@ -289,6 +305,15 @@ func TestDuplicateCredentials(t *testing.T) {
 			},
 			wantReturn: true,
 		},
+		{
+			name: "Return true if same username and env with another case",
+			cred: auth.Credential{
+				Username:    "user1",
+				Environment: "ENV1",
+				Password:    "pass1",
+			},
+			wantReturn: true,
+		},
 		{
 			name: "Return false if same username and different env",
 			cred: auth.Credential{
@@ -348,3 +373,104 @@ func TestDuplicateCredentials(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "credentials.json")
+
+	repo := auth.NewJSONRepository(filePath)
+	require.NoError(t, repo.Add(auth.Credential{Username: "user1", Password: "pass1", Environment: "env1"}))
+	require.NoError(t, repo.Add(auth.Credential{Username: "user2", Password: "pass2", Environment: "env1"}))
+
+	creds, err := repo.GetAll()
+	require.NoError(t, err)
+
+	t.Run("updates existing credential in place", func(t *testing.T) {
+		updated := creds[0]
+		updated.Password = "rotated"
+
+		require.NoError(t, repo.Update(updated))
+
+		got, err := repo.GetAll()
+		require.NoError(t, err)
+		updated.Revision++
+		unchanged := creds[1]
+		unchanged.Revision++
+		assert.Equal(t, auth.Credentials{updated, unchanged}, got)
+	})
+
+	t.Run("stale credential returns a conflict", func(t *testing.T) {
+		cred, err := repo.GetById(creds[1].ID)
+		require.NoError(t, err)
+
+		other := auth.NewJSONRepository(filePath)
+		changed, err := other.GetById(creds[1].ID)
+		require.NoError(t, err)
+		changed.Password = "changed elsewhere"
+		require.NoError(t, other.Update(changed))
+
+		cred.Password = "stale"
+		err = repo.Update(cred)
+		require.ErrorIs(t, err, auth.ErrConflict)
+
+		var conflict *auth.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, cred.Revision, conflict.Expected)
+		assert.Equal(t, cred.Revision+1, conflict.Actual)
+
+		got, err := repo.GetById(creds[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "changed elsewhere", got.Password, "the stale update must not be applied")
+	})
+
+	t.Run("duplicate check ignores the credential itself", func(t *testing.T) {
+		isDuplicate, err := repo.CheckDuplicate(creds[0])
+		require.NoError(t, err)
+		assert.False(t, isDuplicate)
+
+		renamed := creds[1]
+		renamed.Username = "user1"
+		isDuplicate, err = repo.CheckDuplicate(renamed)
+		require.NoError(t, err)
+		assert.True(t, isDuplicate)
+	})
+
+	t.Run("unknown ID returns not found", func(t *testing.T) {
+		current, err := repo.GetAll()
+		require.NoError(t, err)
+
+		err = repo.Update(auth.Credential{ID: "missing", Username: "user", Password: "pass", Environment: "env1", Revision: current[0].Revision})
+		assert.ErrorIs(t, err, auth.ErrCredentialNotFound)
+	})
+}
+
+func TestGetById(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "credentials.json")
+
+	repo := auth.NewJSONRepository(filePath)
+	require.NoError(t, repo.Add(auth.Credential{ID: "a", Username: "user1", Password: "pass1", Environment: "env1"}))
+
+	t.Run("returns the credential with its secrets", func(t *testing.T) {
+		cred, err := repo.GetById("a")
+		require.NoError(t, err)
+		assert.Equal(t, "pass1", cred.Password)
+	})
+
+	t.Run("unknown ID returns not found", func(t *testing.T) {
+		_, err := repo.GetById("missing")
+		assert.ErrorIs(t, err, auth.ErrCredentialNotFound)
+	})
+
+	t.Run("vault opens the credential", func(t *testing.T) {
+		vaultPath := filepath.Join(t.TempDir(), "credentials.json")
+		vault := auth.NewEncryptedRepository(vaultPath, "correct horse")
+		require.NoError(t, vault.Add(auth.Credential{ID: "a", Username: "user1", Password: "pass1", Environment: "env1"}))
+
+		cred, err := auth.NewEncryptedRepository(vaultPath, "correct horse").GetById("a")
+		require.NoError(t, err)
+		assert.Equal(t, "pass1", cred.Password)
+
+		_, err = auth.NewEncryptedRepository(vaultPath, "correct horse").GetById("missing")
+		assert.ErrorIs(t, err, auth.ErrCredentialNotFound)
+	})
+}
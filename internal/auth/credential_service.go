@@ -1,6 +1,14 @@
 package auth
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MinIDPrefixLength is the shortest ID prefix Resolve accepts.
+const MinIDPrefixLength = 4
 
 type CredentialService struct {
 	repo CredentialRepository
@@ -10,7 +18,34 @@ func NewCredentialService(repo CredentialRepository) *CredentialService {
 	return &CredentialService{repo: repo}
 }
 
-func (s *CredentialService) AddCredential(cred Credential) error {
+// AddCredential stores a new credential and returns it with its assigned ID.
+func (s *CredentialService) AddCredential(cred Credential) (Credential, error) {
+	if err := cred.Validate(); err != nil {
+		return Credential{}, fmt.Errorf("invalid credential: %w", err)
+	}
+
+	isDuplicate, err := s.repo.CheckDuplicate(cred)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if isDuplicate {
+		return Credential{}, fmt.Errorf("credential for environment '%s' and username '%s' already exists", cred.Environment, cred.Username)
+	}
+
+	if err := s.checkNickname(cred); err != nil {
+		return Credential{}, err
+	}
+
+	cred.ID = uuid.New().String()
+	if err := s.repo.Add(cred); err != nil {
+		return Credential{}, fmt.Errorf("failed to add credential: %w", err)
+	}
+
+	return cred, nil
+}
+
+func (s *CredentialService) UpdateCredential(cred Credential) error {
 	if err := cred.Validate(); err != nil {
 		return fmt.Errorf("invalid credential: %w", err)
 	}
@@ -24,19 +59,62 @@ func (s *CredentialService) AddCredential(cred Credential) error {
 		return fmt.Errorf("credential for environment '%s' and username '%s' already exists", cred.Environment, cred.Username)
 	}
 
-	if err := s.repo.Add(cred); err != nil {
-		return fmt.Errorf("failed to add credential: %w", err)
+	if err := s.checkNickname(cred); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(cred); err != nil {
+		return fmt.Errorf("failed to update credential: %w", err)
 	}
 
 	return nil
 }
 
+// CacheToken stores token as the cached token of the credential with the
+// given ID. A nil token clears the cache.
+func (s *CredentialService) CacheToken(id string, token *Token) error {
+	cred, err := s.repo.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	cred.Token = token
+	if err := s.repo.Update(cred); err != nil {
+		return fmt.Errorf("failed to cache token: %w", err)
+	}
+
+	return nil
+}
+
+// ListCredentials returns the credentials of env, or all of them when env is
+// empty, without their secrets.
 func (s *CredentialService) ListCredentials(env string) (Credentials, error) {
-	if env != "" {
-		return s.repo.GetByEnv(env)
+	creds, err := s.repo.ListMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	if env == "" {
+		return creds, nil
 	}
 
-	return s.repo.GetAll()
+	var filtered Credentials
+	for _, c := range creds {
+		if c.Environment == env {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered, nil
+}
+
+// ListCredentialsWithSecrets is ListCredentials for commands that use the
+// secrets, which unlocks an encrypted store.
+func (s *CredentialService) ListCredentialsWithSecrets(env string) (Credentials, error) {
+	if env == "" {
+		return s.repo.GetAll()
+	}
+	return s.repo.GetByEnv(env)
 }
 
 func (s *CredentialService) RemoveCredential(id string) error {
@@ -46,3 +124,96 @@ func (s *CredentialService) RemoveCredential(id string) error {
 
 	return nil
 }
+
+func (s *CredentialService) GetCredential(id string) (Credential, error) {
+	return s.repo.GetById(id)
+}
+
+// Resolve finds the credential a user refers to. In order of precedence ref
+// can be a full ID, a nickname, an "environment/username" pair, an
+// "environment/nickname" pair or a unique prefix of at least
+// MinIDPrefixLength characters of an ID, like git does for commit hashes.
+// Nicknames are only unique within an environment, so the pair names a
+// nickname that is used in several of them. The credential is returned
+// without its secrets, see ResolveWithSecrets.
+func (s *CredentialService) Resolve(ref string) (Credential, error) {
+	creds, err := s.repo.ListMetadata()
+	if err != nil {
+		return Credential{}, err
+	}
+
+	for _, c := range creds {
+		if c.ID == ref {
+			return c, nil
+		}
+	}
+
+	matchers := []func(c Credential) bool{
+		func(c Credential) bool {
+			return c.Nickname != "" && c.Nickname == ref
+		},
+		func(c Credential) bool {
+			env, username, ok := strings.Cut(ref, "/")
+			return ok && SameEnvironment(c.Environment, env) && c.Username == username
+		},
+		func(c Credential) bool {
+			env, nickname, ok := strings.Cut(ref, "/")
+			return ok && c.Nickname != "" && SameEnvironment(c.Environment, env) && c.Nickname == nickname
+		},
+		func(c Credential) bool {
+			return len(ref) >= MinIDPrefixLength && strings.HasPrefix(c.ID, strings.ToLower(ref))
+		},
+	}
+
+	for _, matches := range matchers {
+		var candidates Credentials
+		for _, c := range creds {
+			if matches(c) {
+				candidates = append(candidates, c)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			return Credential{}, &AmbiguousReferenceError{Ref: ref, Candidates: candidates}
+		}
+	}
+
+	return Credential{}, ErrCredentialNotFound
+}
+
+// ResolveWithSecrets is Resolve for commands that use the secrets, which
+// unlocks an encrypted store.
+func (s *CredentialService) ResolveWithSecrets(ref string) (Credential, error) {
+	cred, err := s.Resolve(ref)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	return s.repo.GetById(cred.ID)
+}
+
+// checkNickname makes sure no other credential of the same environment uses
+// the nickname of cred.
+func (s *CredentialService) checkNickname(cred Credential) error {
+	if cred.Nickname == "" {
+		return nil
+	}
+
+	creds, err := s.repo.ListMetadata()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range creds {
+		if c.ID != cred.ID && SameEnvironment(c.Environment, cred.Environment) && c.Nickname == cred.Nickname {
+			return fmt.Errorf("nickname '%s' is already used in environment '%s'", cred.Nickname, cred.Environment)
+		}
+	}
+
+	return nil
+}
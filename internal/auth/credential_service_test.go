@@ -3,6 +3,7 @@ package auth_test
 import (
 	"jpellissari/dwing/internal/auth"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,6 +25,9 @@ func (r *FakeCredentialRepository) Add(cred auth.Credential) error {
 
 func (r *FakeCredentialRepository) CheckDuplicate(cred auth.Credential) (bool, error) {
 	for _, c := range r.Credentials {
+		if cred.ID != "" && c.ID == cred.ID {
+			continue
+		}
 		if c.Environment == cred.Environment && c.Username == cred.Username && c.Nickname == cred.Nickname {
 			return true, nil
 		}
@@ -32,7 +36,12 @@ func (r *FakeCredentialRepository) CheckDuplicate(cred auth.Credential) (bool, e
 }
 
 func (r *FakeCredentialRepository) GetById(id string) (auth.Credential, error) {
-	return auth.Credential{}, nil
+	for _, c := range r.Credentials {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return auth.Credential{}, auth.ErrCredentialNotFound
 }
 
 func (r *FakeCredentialRepository) GetByEnv(env string) (auth.Credentials, error) {
@@ -56,6 +65,24 @@ func (r *FakeCredentialRepository) RemoveById(id string) error {
 	return auth.ErrCredentialNotFound
 }
 
+func (r *FakeCredentialRepository) Update(cred auth.Credential) error {
+	for i, c := range r.Credentials {
+		if c.ID == cred.ID {
+			r.Credentials[i] = cred
+			return nil
+		}
+	}
+	return auth.ErrCredentialNotFound
+}
+
+func (r *FakeCredentialRepository) ListMetadata() (auth.Credentials, error) {
+	creds := make(auth.Credentials, 0, len(r.Credentials))
+	for _, c := range r.Credentials {
+		creds = append(creds, c.Metadata())
+	}
+	return creds, nil
+}
+
 func (r *FakeCredentialRepository) GetAll() (auth.Credentials, error) {
 	return r.Credentials, nil
 }
@@ -73,16 +100,16 @@ func TestListCredentials(t *testing.T) {
 			expectList:  auth.Credentials{},
 		},
 		{
-			name: "return all credentials",
+			name: "return all credentials without secrets",
 			credentials: auth.Credentials{
 				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1"},
 				{Environment: "env1", Username: "user2", Password: "pass2", Nickname: "nick2"},
 				{Environment: "env1", Username: "user3", Password: "pass3", Nickname: "nick3"},
 			},
 			expectList: auth.Credentials{
-				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1"},
-				{Environment: "env1", Username: "user2", Password: "pass2", Nickname: "nick2"},
-				{Environment: "env1", Username: "user3", Password: "pass3", Nickname: "nick3"},
+				{Environment: "env1", Username: "user1", Nickname: "nick1"},
+				{Environment: "env1", Username: "user2", Nickname: "nick2"},
+				{Environment: "env1", Username: "user3", Nickname: "nick3"},
 			},
 		},
 		{
@@ -93,7 +120,7 @@ func TestListCredentials(t *testing.T) {
 				{Environment: "env2", Username: "user3", Password: "pass3", Nickname: "nick3"},
 			},
 			expectList: auth.Credentials{
-				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1"},
+				{Environment: "env1", Username: "user1", Nickname: "nick1"},
 			},
 			envFilter: "env1",
 		},
@@ -112,6 +139,22 @@ func TestListCredentials(t *testing.T) {
 	}
 }
 
+func TestListCredentialsWithSecrets(t *testing.T) {
+	repo := NewFakeCredentialRepository(auth.Credentials{
+		{Environment: "env1", Username: "user1", Password: "pass1"},
+		{Environment: "env2", Username: "user2", Password: "pass2"},
+	})
+	service := auth.NewCredentialService(repo)
+
+	all, err := service.ListCredentialsWithSecrets("")
+	assert.NoError(t, err)
+	assert.Equal(t, repo.Credentials, all)
+
+	filtered, err := service.ListCredentialsWithSecrets("env2")
+	assert.NoError(t, err)
+	assert.Equal(t, auth.Credentials{{Environment: "env2", Username: "user2", Password: "pass2"}}, filtered)
+}
+
 func TestRemoveCredential(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -157,3 +200,203 @@ func TestRemoveCredential(t *testing.T) {
 		})
 	}
 }
+
+func TestResolve(t *testing.T) {
+	credentials := auth.Credentials{
+		{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "db", ID: "3f2a9c10-0000-0000-0000-000000000001"},
+		{Environment: "env2", Username: "user2", Password: "pass2", Nickname: "api", ID: "3f2a9c10-0000-0000-0000-000000000002"},
+		{Environment: "env3", Username: "user3", Password: "pass3", Nickname: "api", ID: "7b11d4e0-0000-0000-0000-000000000003"},
+		{Environment: "env3", Username: "7b11", Password: "pass4", ID: "9c00e2a1-0000-0000-0000-000000000004"},
+	}
+
+	testCases := []struct {
+		name        string
+		ref         string
+		expectID    string
+		expectError error
+	}{
+		{name: "full ID", ref: "3f2a9c10-0000-0000-0000-000000000002", expectID: "3f2a9c10-0000-0000-0000-000000000002"},
+		{name: "nickname", ref: "db", expectID: "3f2a9c10-0000-0000-0000-000000000001"},
+		{name: "environment and username", ref: "env2/user2", expectID: "3f2a9c10-0000-0000-0000-000000000002"},
+		{name: "environment is case insensitive", ref: "ENV2/user2", expectID: "3f2a9c10-0000-0000-0000-000000000002"},
+		{name: "environment and nickname", ref: "env3/api", expectID: "7b11d4e0-0000-0000-0000-000000000003"},
+		{name: "nickname of another environment", ref: "env1/api", expectError: auth.ErrCredentialNotFound},
+		{name: "unique ID prefix", ref: "7b11d", expectID: "7b11d4e0-0000-0000-0000-000000000003"},
+		{name: "uppercase ID prefix", ref: "9C00", expectID: "9c00e2a1-0000-0000-0000-000000000004"},
+		{name: "ambiguous ID prefix", ref: "3f2a", expectError: auth.ErrAmbiguousReference},
+		{name: "ambiguous nickname", ref: "api", expectError: auth.ErrAmbiguousReference},
+		{name: "prefix too short", ref: "7b1", expectError: auth.ErrCredentialNotFound},
+		{name: "unknown username", ref: "env1/nobody", expectError: auth.ErrCredentialNotFound},
+		{name: "not found", ref: "missing", expectError: auth.ErrCredentialNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := auth.NewCredentialService(NewFakeCredentialRepository(credentials))
+
+			cred, err := service.Resolve(tc.ref)
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectID, cred.ID)
+			}
+		})
+	}
+
+	t.Run("ambiguous reference lists candidates", func(t *testing.T) {
+		service := auth.NewCredentialService(NewFakeCredentialRepository(credentials))
+
+		_, err := service.Resolve("api")
+
+		var ambiguous *auth.AmbiguousReferenceError
+		assert.ErrorAs(t, err, &ambiguous)
+		assert.Len(t, ambiguous.Candidates, 2)
+		assert.Contains(t, err.Error(), "env2/user2")
+		assert.Contains(t, err.Error(), "env3/user3")
+	})
+}
+
+func TestCacheToken(t *testing.T) {
+	repo := NewFakeCredentialRepository(auth.Credentials{
+		{ID: "id1", Environment: "env1", Username: "user1", Password: "pass1"},
+	})
+	service := auth.NewCredentialService(repo)
+	token := &auth.Token{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: time.Unix(1700000000, 0)}
+
+	assert.NoError(t, service.CacheToken("id1", token))
+	assert.Equal(t, token, repo.Credentials[0].Token)
+	assert.Equal(t, "pass1", repo.Credentials[0].Password)
+
+	assert.NoError(t, service.CacheToken("id1", nil))
+	assert.Nil(t, repo.Credentials[0].Token)
+
+	assert.ErrorIs(t, service.CacheToken("missing", token), auth.ErrCredentialNotFound)
+}
+
+func TestUpdateCredential(t *testing.T) {
+	testCases := []struct {
+		name        string
+		credentials auth.Credentials
+		update      auth.Credential
+		expectError bool
+	}{
+		{
+			name: "update password keeps the ID",
+			credentials: auth.Credentials{
+				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1", ID: "1"},
+			},
+			update:      auth.Credential{Environment: "env1", Username: "user1", Password: "new", Nickname: "nick1", ID: "1"},
+			expectError: false,
+		},
+		{
+			name: "error when update collides with another credential",
+			credentials: auth.Credentials{
+				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1", ID: "1"},
+				{Environment: "env1", Username: "user2", Password: "pass2", Nickname: "nick1", ID: "2"},
+			},
+			update:      auth.Credential{Environment: "env1", Username: "user1", Password: "pass2", Nickname: "nick1", ID: "2"},
+			expectError: true,
+		},
+		{
+			name: "error when update is invalid",
+			credentials: auth.Credentials{
+				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1", ID: "1"},
+			},
+			update:      auth.Credential{Environment: "env1", Username: "user1", Password: "", ID: "1"},
+			expectError: true,
+		},
+		{
+			name: "error when credential does not exist",
+			credentials: auth.Credentials{
+				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1", ID: "1"},
+			},
+			update:      auth.Credential{Environment: "env1", Username: "user9", Password: "pass9", ID: "9"},
+			expectError: true,
+		},
+		{
+			name: "error when nickname is taken in the environment",
+			credentials: auth.Credentials{
+				{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "nick1", ID: "1"},
+				{Environment: "env1", Username: "user2", Password: "pass2", Nickname: "nick2", ID: "2"},
+			},
+			update:      auth.Credential{Environment: "env1", Username: "user2", Password: "pass2", Nickname: "nick1", ID: "2"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewFakeCredentialRepository(tc.credentials)
+			service := auth.NewCredentialService(repo)
+
+			err := service.UpdateCredential(tc.update)
+
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				creds, _ := repo.GetAll()
+				assert.Contains(t, creds, tc.update)
+			}
+		})
+	}
+}
+
+func TestAddCredential(t *testing.T) {
+	t.Run("assigns an ID to the new credential", func(t *testing.T) {
+		repo := NewFakeCredentialRepository(auth.Credentials{})
+		service := auth.NewCredentialService(repo)
+
+		added, err := service.AddCredential(auth.Credential{Environment: "env1", Username: "user1", Password: "pass1"})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, added.ID)
+		assert.Equal(t, auth.Credentials{added}, repo.Credentials)
+	})
+
+	t.Run("error when credential is a duplicate", func(t *testing.T) {
+		repo := NewFakeCredentialRepository(auth.Credentials{
+			{Environment: "env1", Username: "user1", Password: "pass1", ID: "1"},
+		})
+		service := auth.NewCredentialService(repo)
+
+		_, err := service.AddCredential(auth.Credential{Environment: "env1", Username: "user1", Password: "pass2"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("error when nickname is taken in the environment", func(t *testing.T) {
+		repo := NewFakeCredentialRepository(auth.Credentials{
+			{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "db", ID: "1"},
+		})
+		service := auth.NewCredentialService(repo)
+
+		_, err := service.AddCredential(auth.Credential{Environment: "env1", Username: "user2", Password: "pass2", Nickname: "db"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("error when nickname is taken in the environment with another case", func(t *testing.T) {
+		repo := NewFakeCredentialRepository(auth.Credentials{
+			{Environment: "prod", Username: "user1", Password: "pass1", Nickname: "db", ID: "1"},
+		})
+		service := auth.NewCredentialService(repo)
+
+		_, err := service.AddCredential(auth.Credential{Environment: "Prod", Username: "user2", Password: "pass2", Nickname: "db"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("same nickname in another environment", func(t *testing.T) {
+		repo := NewFakeCredentialRepository(auth.Credentials{
+			{Environment: "env1", Username: "user1", Password: "pass1", Nickname: "db", ID: "1"},
+		})
+		service := auth.NewCredentialService(repo)
+
+		_, err := service.AddCredential(auth.Credential{Environment: "env2", Username: "user1", Password: "pass2", Nickname: "db"})
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package auth
+
+import "fmt"
+
+// EncryptedRepository stores credentials in a passphrase protected vault.
+// It behaves exactly like JSONRepository, except that secrets are encrypted
+// on disk with a key derived from the passphrase. Metadata stays readable,
+// so listing credentials never asks for the passphrase.
+type EncryptedRepository struct {
+	*JSONRepository
+}
+
+func NewEncryptedRepository(filePath string, passphrase string) *EncryptedRepository {
+	return NewEncryptedRepositoryFunc(filePath, func() (string, error) {
+		return passphrase, nil
+	})
+}
+
+// NewEncryptedRepositoryFunc is like NewEncryptedRepository, but only calls
+// passphrase once a secret has to be decrypted or encrypted.
+func NewEncryptedRepositoryFunc(filePath string, passphrase func() (string, error)) *EncryptedRepository {
+	return &EncryptedRepository{
+		JSONRepository: &JSONRepository{
+			filePath: filePath,
+			keyring:  newVaultKeyring(passphrase),
+		},
+	}
+}
+
+// Unlock verifies the passphrase without decrypting any credential. Any
+// passphrase unlocks a vault that does not exist yet.
+func (r *EncryptedRepository) Unlock() error {
+	file, err := r.read()
+	if err != nil {
+		return err
+	}
+
+	if file.Vault != nil {
+		_, err = r.keyring.unlock(file.Vault)
+	}
+
+	return err
+}
+
+// Upgrade rewrites a version 1 vault in the current format and reports
+// whether there was anything to upgrade.
+func (r *EncryptedRepository) Upgrade() (bool, error) {
+	unlock, err := r.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	current, err := r.load()
+	if err != nil {
+		return false, err
+	}
+
+	if !current.legacy {
+		return false, nil
+	}
+
+	current.Revision++
+	if err := r.write(current); err != nil {
+		return false, fmt.Errorf("failed to upgrade vault: %w", err)
+	}
+
+	return true, nil
+}
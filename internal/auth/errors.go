@@ -1,7 +1,57 @@
 package auth
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrCredentialNotFound = errors.New("credential not found")
+	ErrInvalidPassphrase  = errors.New("invalid passphrase or corrupted vault")
+	ErrEmptyPassphrase    = errors.New("passphrase cannot be empty")
+	ErrNotAVault          = errors.New("file is not an encrypted dwing vault")
+	ErrUnsupportedVault   = errors.New("unsupported vault version")
+	ErrConflict           = errors.New("credentials were modified concurrently")
+	ErrAmbiguousReference = errors.New("credential reference is ambiguous")
+	ErrVaultLocked        = errors.New("credential store is encrypted and locked")
+	ErrCorruptVault       = errors.New("vault is corrupted or was tampered with")
 )
+
+// AmbiguousReferenceError is returned when a reference matches several
+// credentials.
+type AmbiguousReferenceError struct {
+	Ref        string
+	Candidates Credentials
+}
+
+func (e *AmbiguousReferenceError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: '%s' matches %d credentials:", ErrAmbiguousReference, e.Ref, len(e.Candidates))
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n  %s  %s/%s", c.ID, c.Environment, c.Username)
+		if c.Nickname != "" {
+			fmt.Fprintf(&b, " (%s)", c.Nickname)
+		}
+	}
+	return b.String()
+}
+
+func (e *AmbiguousReferenceError) Is(target error) bool {
+	return target == ErrAmbiguousReference
+}
+
+// ConflictError is returned when the credentials file changed between the
+// time it was read and the time it was written.
+type ConflictError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: expected revision %d, found %d", ErrConflict, e.Expected, e.Actual)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -0,0 +1,37 @@
+package auth
+
+import "time"
+
+// Token is an OAuth2 token obtained with a credential. The last one is
+// cached on the credential, as a secret.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitzero"`
+}
+
+// Expired reports whether the token has expired at now. A token without an
+// expiry never does.
+func (t Token) Expired(now time.Time) bool {
+	if t.ExpiresAt.IsZero() {
+		return false
+	}
+	return !now.Before(t.ExpiresAt)
+}
+
+// Redacted returns a copy of the token with its secrets masked.
+func (t Token) Redacted() Token {
+	if t.AccessToken != "" {
+		t.AccessToken = RedactedSecret
+	}
+	if t.RefreshToken != "" {
+		t.RefreshToken = RedactedSecret
+	}
+	if t.IDToken != "" {
+		t.IDToken = RedactedSecret
+	}
+	return t
+}
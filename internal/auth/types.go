@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	TypePassword          = "password"
+	TypeAPIKey            = "api_key"
+	TypeBearerToken       = "bearer_token"
+	TypeSSHKey            = "ssh_key"
+	TypeClientCredentials = "client_credentials"
+	TypeAWS               = "aws"
+	TypeDatabase          = "database"
+)
+
+// Names of the fields stored directly on Credential. Every other field of a
+// schema lives in Credential.Fields.
+const (
+	FieldUsername = "username"
+	FieldPassword = "password"
+	FieldOTPSeed  = "otp_seed"
+)
+
+var ErrUnknownType = errors.New("unknown credential type")
+
+// FieldSpec describes one field of a credential type.
+type FieldSpec struct {
+	Name        string
+	Label       string
+	Description string
+	Required    bool
+	Secret      bool
+	Multiline   bool
+}
+
+// CredentialType is the schema of a kind of credential. Every type has a
+// username, which identifies the credential within its environment, and
+// most use the password field for their main secret under another label.
+type CredentialType struct {
+	Name        string
+	Description string
+	Fields      []FieldSpec
+
+	validate func(c Credential) error
+}
+
+// Field returns the spec of the named field.
+func (t CredentialType) Field(name string) (FieldSpec, bool) {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FieldSpec{}, false
+}
+
+// PrimarySecret returns the field holding the main secret of the type: the
+// first required secret field, or the password.
+func (t CredentialType) PrimarySecret() string {
+	for _, f := range t.Fields {
+		if f.Required && f.Secret {
+			return f.Name
+		}
+	}
+	return FieldPassword
+}
+
+var (
+	awsAccessKeyID = regexp.MustCompile(`^(AKIA|ASIA)[A-Z0-9]{16}$`)
+	otpSeedField   = FieldSpec{Name: FieldOTPSeed, Label: "OTP seed", Description: "Base32 secret or otpauth:// URI from the QR code", Secret: true}
+)
+
+var Types = []CredentialType{
+	{
+		Name:        TypePassword,
+		Description: "Username and password",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "Username", Required: true},
+			{Name: FieldPassword, Label: "Password", Required: true, Secret: true},
+			otpSeedField,
+		},
+	},
+	{
+		Name:        TypeAPIKey,
+		Description: "API key sent in a header",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "Key name", Required: true},
+			{Name: FieldPassword, Label: "API key", Required: true, Secret: true},
+			{Name: "header", Label: "Header", Description: "Header the key is sent in, e.g. X-API-Key"},
+		},
+	},
+	{
+		Name:        TypeBearerToken,
+		Description: "Static bearer token",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "Token name", Required: true},
+			{Name: FieldPassword, Label: "Token", Required: true, Secret: true},
+		},
+	},
+	{
+		Name:        TypeSSHKey,
+		Description: "SSH private key",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "User", Required: true},
+			{Name: "private_key", Label: "Private key", Required: true, Secret: true, Multiline: true},
+			{Name: FieldPassword, Label: "Key passphrase", Secret: true},
+			{Name: "host", Label: "Host"},
+		},
+		validate: func(c Credential) error {
+			if !strings.HasPrefix(strings.TrimSpace(c.Fields["private_key"]), "-----BEGIN ") {
+				return errors.New("private_key must be a PEM encoded private key")
+			}
+			return nil
+		},
+	},
+	{
+		Name:        TypeClientCredentials,
+		Description: "OAuth2 client ID and secret",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "Client ID", Required: true},
+			{Name: FieldPassword, Label: "Client secret", Required: true, Secret: true},
+			{Name: "token_url", Label: "Token URL"},
+			{Name: "scopes", Label: "Scopes", Description: "Space separated scopes to request"},
+		},
+	},
+	{
+		Name:        TypeAWS,
+		Description: "AWS access key",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "Access key ID", Required: true},
+			{Name: FieldPassword, Label: "Secret access key", Required: true, Secret: true},
+			{Name: "session_token", Label: "Session token", Secret: true},
+			{Name: "region", Label: "Region"},
+		},
+		validate: func(c Credential) error {
+			if !awsAccessKeyID.MatchString(c.Username) {
+				return errors.New("username must be an AWS access key ID, e.g. AKIA...")
+			}
+			return nil
+		},
+	},
+	{
+		Name:        TypeDatabase,
+		Description: "Database user",
+		Fields: []FieldSpec{
+			{Name: FieldUsername, Label: "Username", Required: true},
+			{Name: FieldPassword, Label: "Password", Required: true, Secret: true},
+			{Name: "host", Label: "Host", Required: true},
+			{Name: "port", Label: "Port"},
+			{Name: "database", Label: "Database"},
+			{Name: "driver", Label: "Driver", Description: "e.g. postgres, mysql"},
+		},
+		validate: func(c Credential) error {
+			if port := c.Fields["port"]; port != "" {
+				if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+					return fmt.Errorf("port must be a number between 1 and 65535, got %q", port)
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// TypeNames lists the names of every credential type.
+func TypeNames() []string {
+	names := make([]string, 0, len(Types))
+	for _, t := range Types {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// LookupType returns the schema of the named type. An empty name is the
+// password type, which every credential had before types existed.
+func LookupType(name string) (CredentialType, error) {
+	if name == "" {
+		name = TypePassword
+	}
+
+	for _, t := range Types {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	return CredentialType{}, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownType, name, strings.Join(TypeNames(), ", "))
+}
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// A vault is a credentials file whose secrets are encrypted. Since version 2
+// it is a regular JSON document, so metadata can be listed without the
+// passphrase:
+//
+//	{
+//	  "revision": 3,
+//	  "vault": {"version": 2, "time": 3, "memory": 65536, "threads": 4, "salt": "...", "check": "..."},
+//	  "credentials": [{"id": "...", "environment": "dev", "username": "alice", "secret": "..."}]
+//	}
+//
+// The key is derived from the passphrase with argon2id. Check is a known
+// value sealed with the key, used to verify the passphrase before touching
+// any secret. Every secret is sealed on its own with AES-256-GCM as
+// nonce||ciphertext, using the credential ID as additional data so secrets
+// cannot be swapped between credentials.
+const (
+	vaultVersion   = 2
+	vaultSaltSize  = 16
+	vaultKeySize   = 32
+	vaultNonceSize = 12
+	vaultCheck     = "dwing-vault-check"
+)
+
+// Version 1 vaults sealed the whole file behind a binary header that is
+// authenticated together with the ciphertext:
+//
+//	magic   [5]byte  "DWVLT"
+//	version uint8    1
+//	time    uint32   argon2id iterations
+//	memory  uint32   argon2id memory in KiB
+//	threads uint8    argon2id parallelism
+//	salt    [16]byte
+//	nonce   [12]byte
+//
+// They are still read, and rewritten as version 2 on the next change.
+const (
+	legacyVaultVersion    = 1
+	legacyVaultMagic      = "DWVLT"
+	legacyVaultHeaderSize = len(legacyVaultMagic) + 1 + 4 + 4 + 1 + vaultSaltSize + vaultNonceSize
+)
+
+type kdfParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+var defaultKDFParams = kdfParams{time: 3, memory: 64 * 1024, threads: 4}
+
+// vaultParams is the "vault" section of a version 2 vault.
+type vaultParams struct {
+	Version int    `json:"version"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	Salt    []byte `json:"salt"`
+	Check   []byte `json:"check"`
+}
+
+func (p vaultParams) kdf() kdfParams {
+	return kdfParams{time: p.Time, memory: p.Memory, threads: p.Threads}
+}
+
+// credentialSecrets holds the fields of a credential that are sealed in a
+// vault.
+type credentialSecrets struct {
+	Password string            `json:"password"`
+	OTPSeed  string            `json:"otp_seed,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Token    *Token            `json:"token,omitempty"`
+}
+
+func secretsOf(c Credential) credentialSecrets {
+	return credentialSecrets{Password: c.Password, OTPSeed: c.OTPSeed, Fields: c.secretFields(), Token: c.Token}
+}
+
+func (s credentialSecrets) applyTo(c *Credential) {
+	c.Password = s.Password
+	c.OTPSeed = s.OTPSeed
+	c.Token = s.Token
+	for name, value := range s.Fields {
+		c.Set(name, value)
+	}
+}
+
+// vaultKeyring asks for the passphrase the first time a secret is needed and
+// caches the derived key per salt, so argon2 only runs once for the lifetime
+// of the repository.
+type vaultKeyring struct {
+	passphrase func() (string, error)
+
+	mu     sync.Mutex
+	secret string
+	params kdfParams
+	salt   []byte
+	key    []byte
+}
+
+func newVaultKeyring(passphrase func() (string, error)) *vaultKeyring {
+	return &vaultKeyring{passphrase: passphrase}
+}
+
+func (k *vaultKeyring) deriveKey(params kdfParams, salt []byte) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.key != nil && k.params == params && bytes.Equal(k.salt, salt) {
+		return k.key, nil
+	}
+
+	if k.secret == "" {
+		secret, err := k.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if secret == "" {
+			return nil, ErrEmptyPassphrase
+		}
+		k.secret = secret
+	}
+
+	k.params = params
+	k.salt = bytes.Clone(salt)
+	k.key = argon2.IDKey([]byte(k.secret), salt, params.time, params.memory, params.threads, vaultKeySize)
+
+	return k.key, nil
+}
+
+// unlock returns the key of an existing vault, failing with
+// ErrInvalidPassphrase if the passphrase does not open the check value.
+func (k *vaultKeyring) unlock(p *vaultParams) ([]byte, error) {
+	if p.Version != vaultVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVault, p.Version)
+	}
+
+	key, err := k.deriveKey(p.kdf(), p.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := openSecret(key, vaultCheck, p.Check)
+	if err != nil || string(check) != vaultCheck {
+		k.forget()
+		return nil, ErrInvalidPassphrase
+	}
+
+	return key, nil
+}
+
+// create sets up the parameters and key of a new vault.
+func (k *vaultKeyring) create() (*vaultParams, []byte, error) {
+	salt := make([]byte, vaultSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	params := defaultKDFParams
+	key, err := k.deriveKey(params, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	check, err := sealSecret(key, vaultCheck, []byte(vaultCheck))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &vaultParams{
+		Version: vaultVersion,
+		Time:    params.time,
+		Memory:  params.memory,
+		Threads: params.threads,
+		Salt:    salt,
+		Check:   check,
+	}, key, nil
+}
+
+// forget drops a passphrase that turned out to be wrong.
+func (k *vaultKeyring) forget() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.secret = ""
+	k.key = nil
+	k.salt = nil
+}
+
+func sealCredential(key []byte, c Credential) (storedCredential, error) {
+	plaintext, err := json.Marshal(secretsOf(c))
+	if err != nil {
+		return storedCredential{}, fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	sealed, err := sealSecret(key, c.ID, plaintext)
+	if err != nil {
+		return storedCredential{}, err
+	}
+
+	return storedCredential{Credential: c.Metadata(), Secret: sealed}, nil
+}
+
+func openCredential(key []byte, s storedCredential) (Credential, error) {
+	c := s.Credential
+	if s.Secret == nil {
+		return c, nil
+	}
+
+	plaintext, err := openSecret(key, c.ID, s.Secret)
+	if err != nil {
+		return Credential{}, fmt.Errorf("credential %s: %w", c.ID, ErrCorruptVault)
+	}
+
+	var secrets credentialSecrets
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return Credential{}, fmt.Errorf("credential %s: %w", c.ID, ErrCorruptVault)
+	}
+	secrets.applyTo(&c)
+
+	return c, nil
+}
+
+func sealSecret(key []byte, aad string, plaintext []byte) ([]byte, error) {
+	aead, err := newVaultAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, vaultNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, []byte(aad)), nil
+}
+
+func openSecret(key []byte, aad string, data []byte) ([]byte, error) {
+	aead, err := newVaultAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < vaultNonceSize {
+		return nil, ErrCorruptVault
+	}
+
+	return aead.Open(nil, data[:vaultNonceSize], data[vaultNonceSize:], []byte(aad))
+}
+
+// openLegacyVault decrypts a version 1 vault into the JSON document it
+// wraps.
+func (k *vaultKeyring) openLegacyVault(data []byte) ([]byte, error) {
+	if len(data) < legacyVaultHeaderSize {
+		return nil, ErrInvalidPassphrase
+	}
+
+	p := data[len(legacyVaultMagic):]
+	if p[0] != legacyVaultVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVault, p[0])
+	}
+	p = p[1:]
+
+	params := kdfParams{
+		time:    binary.BigEndian.Uint32(p[0:4]),
+		memory:  binary.BigEndian.Uint32(p[4:8]),
+		threads: p[8],
+	}
+	p = p[9:]
+	salt := p[:vaultSaltSize]
+	nonce := p[vaultSaltSize : vaultSaltSize+vaultNonceSize]
+
+	key, err := k.deriveKey(params, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newVaultAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := data[:legacyVaultHeaderSize]
+	plaintext, err := aead.Open(nil, nonce, data[legacyVaultHeaderSize:], header)
+	if err != nil {
+		k.forget()
+		return nil, ErrInvalidPassphrase
+	}
+
+	return plaintext, nil
+}
+
+func newVaultAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return aead, nil
+}
+
+func isLegacyVault(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(legacyVaultMagic))
+}
+
+// IsVault reports whether the file at path is an encrypted dwing vault. A
+// missing file is not a vault.
+func IsVault(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	if isLegacyVault(data) {
+		return true, nil
+	}
+
+	var file struct {
+		Vault *vaultParams `json:"vault"`
+	}
+	if json.Unmarshal(data, &file) != nil {
+		return false, nil
+	}
+
+	return file.Vault != nil, nil
+}
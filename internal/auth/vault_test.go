@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+// writeLegacyVault writes creds as a version 1 vault, sealed as a whole.
+func writeLegacyVault(t *testing.T, path, passphrase string, creds Credentials) {
+	t.Helper()
+
+	plaintext, err := json.Marshal(creds)
+	require.NoError(t, err)
+
+	params := kdfParams{time: 1, memory: 1024, threads: 1}
+	salt := make([]byte, vaultSaltSize)
+	nonce := make([]byte, vaultNonceSize)
+	_, err = rand.Read(salt)
+	require.NoError(t, err)
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+
+	header := []byte(legacyVaultMagic)
+	header = append(header, legacyVaultVersion)
+	header = binary.BigEndian.AppendUint32(header, params.time)
+	header = binary.BigEndian.AppendUint32(header, params.memory)
+	header = append(header, params.threads)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+
+	key := argon2.IDKey([]byte(passphrase), salt, params.time, params.memory, params.threads, vaultKeySize)
+	aead, err := newVaultAEAD(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, aead.Seal(header, nonce, plaintext, header), 0600))
+}
+
+func TestLegacyVault(t *testing.T) {
+	creds := Credentials{
+		{ID: "1", Environment: "dev", Username: "alice", Password: "s3cret"},
+	}
+
+	t.Run("is read with the passphrase", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credentials.json")
+		writeLegacyVault(t, path, "correct horse", creds)
+
+		isVault, err := IsVault(path)
+		require.NoError(t, err)
+		assert.True(t, isVault)
+
+		got, err := NewEncryptedRepository(path, "correct horse").GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, creds, got)
+
+		_, err = NewEncryptedRepository(path, "battery staple").GetAll()
+		assert.ErrorIs(t, err, ErrInvalidPassphrase)
+	})
+
+	t.Run("is upgraded to per-secret encryption", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credentials.json")
+		writeLegacyVault(t, path, "correct horse", creds)
+
+		upgraded, err := NewEncryptedRepository(path, "correct horse").Upgrade()
+		require.NoError(t, err)
+		assert.True(t, upgraded)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "alice")
+		assert.NotContains(t, string(data), "s3cret")
+
+		got, err := NewEncryptedRepository(path, "correct horse").GetAll()
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, int64(1), got[0].Revision)
+		got[0].Revision = 0
+		assert.Equal(t, creds, got)
+
+		upgraded, err = NewEncryptedRepository(path, "correct horse").Upgrade()
+		require.NoError(t, err)
+		assert.False(t, upgraded)
+	})
+
+	t.Run("is upgraded by the next change", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credentials.json")
+		writeLegacyVault(t, path, "correct horse", creds)
+
+		repo := NewEncryptedRepository(path, "correct horse")
+		require.NoError(t, repo.Add(Credential{ID: "2", Environment: "dev", Username: "bob", Password: "hunter2"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.False(t, isLegacyVault(data))
+		assert.NotContains(t, string(data), "s3cret")
+		assert.NotContains(t, string(data), "hunter2")
+
+		got, err := NewEncryptedRepository(path, "correct horse").GetAll()
+		require.NoError(t, err)
+		assert.Len(t, got, 2)
+	})
+}
@@ -0,0 +1,116 @@
+// Package clipboard copies secrets to the system clipboard, falling back to
+// OSC 52 terminal escape sequences when there is no local clipboard, e.g.
+// over SSH.
+package clipboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Method tells how text was copied.
+type Method string
+
+const (
+	// MethodSystem is the clipboard of the machine dwing runs on.
+	MethodSystem Method = "system"
+	// MethodOSC52 asks the terminal to set the clipboard of the machine it
+	// runs on. Terminals do not let dwing read that clipboard back.
+	MethodOSC52 Method = "osc52"
+)
+
+var ErrUnsupported = errors.New("no clipboard available: install xclip, xsel or wl-clipboard, or use a terminal supporting OSC 52")
+
+// Available reports whether text can be copied at all, so commands can check
+// before changing anything.
+func Available() error {
+	if !clipboard.Unsupported {
+		return nil
+	}
+
+	tty, err := Terminal()
+	if err != nil {
+		return ErrUnsupported
+	}
+	return tty.Close()
+}
+
+// Copy puts text on the system clipboard, or sends it to the terminal with
+// OSC 52 when the system clipboard cannot be used.
+func Copy(text string) (Method, error) {
+	if !clipboard.Unsupported && clipboard.WriteAll(text) == nil {
+		return MethodSystem, nil
+	}
+
+	tty, err := Terminal()
+	if err != nil {
+		return "", ErrUnsupported
+	}
+	defer tty.Close()
+
+	if err := WriteOSC52(tty, text); err != nil {
+		return "", err
+	}
+	return MethodOSC52, nil
+}
+
+// WriteOSC52 writes the escape sequence setting the terminal's clipboard to
+// text, wrapped for tmux or screen when running inside them.
+func WriteOSC52(w io.Writer, text string) error {
+	if _, err := wrap(osc52.New(text)).WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+	return nil
+}
+
+// ClearOSC52 writes the escape sequence clearing the terminal's clipboard.
+func ClearOSC52(w io.Writer) error {
+	if _, err := wrap(osc52.Clear()).WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+	return nil
+}
+
+// Sum is the digest used to recognize a copied secret without keeping it.
+func Sum(text string) []byte {
+	sum := sha256.Sum256([]byte(text))
+	return sum[:]
+}
+
+// ClearIfUnchanged empties the system clipboard if it still holds the text
+// whose Sum is sum, and reports whether it did. Anything copied since is
+// left alone.
+func ClearIfUnchanged(sum []byte) (bool, error) {
+	current, err := clipboard.ReadAll()
+	if err != nil {
+		return false, fmt.Errorf("failed to read clipboard: %w", err)
+	}
+
+	if !bytes.Equal(Sum(current), sum) {
+		return false, nil
+	}
+
+	if err := clipboard.WriteAll(""); err != nil {
+		return false, fmt.Errorf("failed to clear clipboard: %w", err)
+	}
+	return true, nil
+}
+
+func wrap(seq osc52.Sequence) osc52.Sequence {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return seq.Screen()
+	default:
+		return seq
+	}
+}
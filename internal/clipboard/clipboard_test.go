@@ -0,0 +1,46 @@
+package clipboard_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"jpellissari/dwing/internal/clipboard"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	var buf bytes.Buffer
+	require.NoError(t, clipboard.WriteOSC52(&buf, "s3cret"))
+
+	assert.Equal(t, "\x1b]52;c;"+base64.StdEncoding.EncodeToString([]byte("s3cret"))+"\x07", buf.String())
+}
+
+func TestWriteOSC52InTmux(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+
+	var buf bytes.Buffer
+	require.NoError(t, clipboard.WriteOSC52(&buf, "s3cret"))
+
+	assert.Contains(t, buf.String(), "\x1bPtmux;")
+}
+
+func TestClearOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	var buf bytes.Buffer
+	require.NoError(t, clipboard.ClearOSC52(&buf))
+
+	assert.NotContains(t, buf.String(), base64.StdEncoding.EncodeToString([]byte("s3cret")))
+	assert.Contains(t, buf.String(), "\x1b]52;c;")
+}
+
+func TestSum(t *testing.T) {
+	assert.Equal(t, clipboard.Sum("s3cret"), clipboard.Sum("s3cret"))
+	assert.NotEqual(t, clipboard.Sum("s3cret"), clipboard.Sum("hunter2"))
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package clipboard
+
+import "os"
+
+// Terminal opens the console for writing, even when stdout and stderr are
+// redirected.
+func Terminal() (*os.File, error) {
+	return os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+}
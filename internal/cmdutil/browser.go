@@ -0,0 +1,46 @@
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// NoBrowserEnv stops dwing from opening URLs in a browser, for remote
+// sessions where the URL is better opened on another machine.
+const NoBrowserEnv = "DWING_NO_BROWSER"
+
+// OpenURL returns a function that prints a URL on the command's standard
+// error and tries to open it in the default browser. The URL is printed in
+// any case as the browser may fail to start without telling.
+func OpenURL(cmd *cobra.Command) func(url string) error {
+	return func(url string) error {
+		if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Open this URL in your browser to log in:\n\n  %s\n\n", url); err != nil {
+			return err
+		}
+		if os.Getenv(NoBrowserEnv) == "" {
+			openBrowser(url)
+		}
+		return nil
+	}
+}
+
+func openBrowser(url string) {
+	var browser *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		browser = exec.Command("open", url)
+	case "windows":
+		browser = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		browser = exec.Command("xdg-open", url)
+	}
+
+	if err := browser.Start(); err != nil {
+		return
+	}
+	go func() { _ = browser.Wait() }()
+}
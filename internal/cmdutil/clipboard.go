@@ -0,0 +1,84 @@
+package cmdutil
+
+import (
+	"encoding/hex"
+	"fmt"
+	"jpellissari/dwing/internal/clipboard"
+	"jpellissari/dwing/internal/config"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// CopySecret copies secret to the clipboard and, unless clipboard_timeout
+// is 0, starts a detached 'creds clear-clipboard' process that clears it
+// once the timeout expires. It returns a description of what happened for
+// the command's success message.
+func CopySecret(cmd *cobra.Command, secret string) (string, error) {
+	after := config.DefaultClipboardTimeout
+	if cfg, err := Config(cmd); err == nil {
+		after = cfg.ClipboardClearAfter()
+	}
+
+	method, err := clipboard.Copy(secret)
+	if err != nil {
+		return "", err
+	}
+
+	description := "copied to the clipboard"
+	if method == clipboard.MethodOSC52 {
+		description = "copied to the terminal's clipboard"
+	}
+
+	if after == 0 {
+		return description, nil
+	}
+
+	if err := scheduleClipboardClear(secret, method, after); err != nil {
+		return "", fmt.Errorf("failed to schedule clipboard clearing: %w", err)
+	}
+
+	return fmt.Sprintf("%s, clearing in %s", description, after), nil
+}
+
+// scheduleClipboardClear re-executes dwing as a detached process that clears
+// the clipboard after the timeout. It only receives a digest of the secret,
+// over stdin, to recognize whether the clipboard still holds it.
+func scheduleClipboardClear(secret string, method clipboard.Method, after time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find dwing executable: %w", err)
+	}
+
+	child := exec.Command(executable, "creds", "clear-clipboard", "--after", after.String(), "--method", string(method))
+	child.SysProcAttr = DetachAttr()
+
+	// The child leaves the session and cannot open the terminal itself, so
+	// it inherits it as stdout to send the OSC 52 clear sequence.
+	if method == clipboard.MethodOSC52 {
+		tty, err := clipboard.Terminal()
+		if err != nil {
+			return err
+		}
+		defer tty.Close()
+		child.Stdout = tty
+	}
+
+	stdin, err := child.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := child.Start(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(stdin, hex.EncodeToString(clipboard.Sum(secret))); err != nil {
+		return err
+	}
+	stdin.Close()
+
+	return child.Process.Release()
+}
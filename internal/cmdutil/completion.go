@@ -0,0 +1,88 @@
+package cmdutil
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/config"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CompleteCredentials completes the first argument with credential IDs,
+// described by their environment, username and nickname. Nicknames are
+// offered as well once the user started typing one.
+func CompleteCredentials(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return CompleteCredentialRefs(cmd, args, toComplete)
+}
+
+// CompleteCredentialRefs is CompleteCredentials for flags and repeated
+// arguments.
+func CompleteCredentialRefs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := Config(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	repo := metadataRepository(cfg)
+	if repo == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	creds, err := repo.ListMetadata()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return credentialCompletions(creds, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func credentialCompletions(creds auth.Credentials, toComplete string) []string {
+	var completions []string
+	for _, c := range creds {
+		description := c.Environment + "/" + c.Username
+		if c.Nickname != "" {
+			description += " (" + c.Nickname + ")"
+		}
+
+		if strings.HasPrefix(c.ID, strings.ToLower(toComplete)) {
+			completions = append(completions, c.ID+"\t"+description)
+		}
+		if toComplete != "" && c.Nickname != "" && strings.HasPrefix(c.Nickname, toComplete) {
+			completions = append(completions, c.Nickname+"\t"+c.Environment+"/"+c.Username)
+		}
+	}
+
+	return completions
+}
+
+// metadataRepository opens the credential store without ever prompting for
+// the vault passphrase, or returns nil.
+func metadataRepository(cfg *config.Config) auth.CredentialRepository {
+	if client := runningAgent(cfg); client != nil {
+		return client
+	}
+
+	switch cfg.Backend {
+	case config.BackendAgent:
+		return nil
+	case config.BackendFile:
+		return auth.NewJSONRepository(cfg.CredentialsPath)
+	}
+
+	encrypted, err := auth.IsVault(cfg.CredentialsPath)
+	if err != nil {
+		return nil
+	}
+
+	if !encrypted {
+		return auth.NewJSONRepository(cfg.CredentialsPath)
+	}
+
+	return auth.NewEncryptedRepositoryFunc(cfg.CredentialsPath, func() (string, error) {
+		return "", auth.ErrVaultLocked
+	})
+}
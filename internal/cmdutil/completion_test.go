@@ -0,0 +1,48 @@
+package cmdutil
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialCompletions(t *testing.T) {
+	creds := auth.Credentials{
+		{ID: "3f2a9c10", Environment: "dev", Username: "alice", Nickname: "db"},
+		{ID: "7b11d4e0", Environment: "prod", Username: "bob"},
+	}
+
+	tests := []struct {
+		name       string
+		toComplete string
+		want       []string
+	}{
+		{
+			name:       "Empty lists every ID",
+			toComplete: "",
+			want:       []string{"3f2a9c10\tdev/alice (db)", "7b11d4e0\tprod/bob"},
+		},
+		{
+			name:       "ID prefix",
+			toComplete: "7B",
+			want:       []string{"7b11d4e0\tprod/bob"},
+		},
+		{
+			name:       "Nickname prefix",
+			toComplete: "d",
+			want:       []string{"db\tdev/alice"},
+		},
+		{
+			name:       "No match",
+			toComplete: "zz",
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, credentialCompletions(creds, tt.toComplete))
+		})
+	}
+}
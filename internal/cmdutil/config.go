@@ -0,0 +1,80 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"jpellissari/dwing/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	ConfigFlag = "config"
+
+	// SkipConfigAnnotation marks commands that must run even when the config
+	// file is broken, such as 'dwing config set'.
+	SkipConfigAnnotation = "dwing:skip-config"
+)
+
+type configKey struct{}
+
+func AddConfigFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(ConfigFlag, "", "Path of the config file (default $XDG_CONFIG_HOME/dwing/config.yaml)")
+}
+
+// ConfigPath returns the config file picked with --config, or the default
+// location.
+func ConfigPath(cmd *cobra.Command) (string, error) {
+	if path, _ := cmd.Flags().GetString(ConfigFlag); path != "" {
+		return path, nil
+	}
+
+	return config.DefaultPath()
+}
+
+// LoadConfig loads the config once for the whole command tree and stores it
+// in the command context. It is meant to run as the root PersistentPreRunE.
+func LoadConfig(cmd *cobra.Command, args []string) error {
+	if _, ok := cmd.Annotations[SkipConfigAnnotation]; ok {
+		return nil
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd.SetContext(context.WithValue(ctx, configKey{}, cfg))
+
+	return nil
+}
+
+// Config returns the config loaded by LoadConfig, loading it on demand for
+// commands that run outside of the root command.
+func Config(cmd *cobra.Command) (*config.Config, error) {
+	if ctx := cmd.Context(); ctx != nil {
+		if cfg, ok := ctx.Value(configKey{}).(*config.Config); ok {
+			return cfg, nil
+		}
+	}
+
+	return loadConfig(cmd)
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	path, err := ConfigPath(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return cfg, nil
+}
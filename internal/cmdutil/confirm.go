@@ -0,0 +1,54 @@
+package cmdutil
+
+import (
+	"errors"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// YesFlag skips confirmation prompts, for scripts.
+const YesFlag = "yes"
+
+var ErrNotConfirmed = errors.New("operation cancelled")
+
+// AddYesFlag registers --yes on a command that asks for confirmation.
+func AddYesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolP(YesFlag, "y", false, "Do not ask for confirmation")
+}
+
+// Confirm asks a yes/no question, defaulting to no. It succeeds without
+// asking when --yes was given, and fails when stdin is not a terminal, so
+// scripts never wait for an answer nobody will give.
+func Confirm(cmd *cobra.Command, title, description string) error {
+	if yes, _ := cmd.Flags().GetBool(YesFlag); yes {
+		return nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		return errors.New("confirmation required, pass --yes to skip it")
+	}
+
+	var confirmed bool
+	err := huh.NewConfirm().
+		Title(title).
+		Description(description).
+		Affirmative("Yes").
+		Negative("No").
+		Value(&confirmed).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return ErrNotConfirmed
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
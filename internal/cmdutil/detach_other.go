@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cmdutil
+
+import "syscall"
+
+func DetachAttr() *syscall.SysProcAttr {
+	return nil
+}
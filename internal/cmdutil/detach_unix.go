@@ -0,0 +1,11 @@
+//go:build unix
+
+package cmdutil
+
+import "syscall"
+
+// DetachAttr starts a child process in its own session so it survives the
+// shell that launched it, like the agent or the clipboard clearer.
+func DetachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,49 @@
+package cmdutil
+
+import (
+	"jpellissari/dwing/internal/environment"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewEnvironmentService returns an environment service backed by the
+// environments file next to the credential store.
+func NewEnvironmentService(cmd *cobra.Command) (*environment.Service, error) {
+	cfg, err := Config(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := environment.NewJSONRepository(environment.PathFor(cfg.CredentialsPath))
+	return environment.NewService(repo), nil
+}
+
+// CompleteEnvironments completes known environment names, described by
+// their aliases.
+func CompleteEnvironments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	service, err := NewEnvironmentService(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	envs, err := service.ListEnvironments()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0, len(envs))
+	for _, env := range envs {
+		if !strings.HasPrefix(strings.ToLower(env.Name), strings.ToLower(toComplete)) {
+			continue
+		}
+
+		description := "danger: " + env.DangerLevel
+		if len(env.Aliases) > 0 {
+			description = "aka " + strings.Join(env.Aliases, ", ") + ", " + description
+		}
+		completions = append(completions, env.Name+"\t"+description)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,13 @@
+package cmdutil
+
+import "fmt"
+
+// ExitError asks main to exit with Code without printing anything else,
+// e.g. to pass through the exit code of a child process.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
@@ -0,0 +1,117 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/clipboard"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/passgen"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	LengthFlag           = "length"
+	ClassesFlag          = "classes"
+	ExcludeAmbiguousFlag = "exclude-ambiguous"
+	PassphraseFlag       = "passphrase"
+	WordsFlag            = "words"
+	SeparatorFlag        = "separator"
+	CopyFlag             = "copy"
+)
+
+// AddGeneratorFlags registers the flags that tune generated passwords and
+// passphrases, and --copy to put the result on the clipboard.
+func AddGeneratorFlags(cmd *cobra.Command) {
+	cmd.Flags().Int(LengthFlag, 0, fmt.Sprintf("Password length (default %d, or the environment's policy)", passgen.DefaultLength))
+	cmd.Flags().StringSlice(ClassesFlag, nil, "Character classes to use: "+strings.Join(passgen.Classes, ", ")+" (default all)")
+	cmd.Flags().Bool(ExcludeAmbiguousFlag, false, "Leave out characters that are easily confused, such as l, 1, O and 0")
+	cmd.Flags().Bool(PassphraseFlag, false, "Generate a diceware passphrase instead of a password")
+	cmd.Flags().Int(WordsFlag, 0, fmt.Sprintf("Number of passphrase words (default %d)", passgen.DefaultWords))
+	cmd.Flags().String(SeparatorFlag, "", fmt.Sprintf("Passphrase word separator (default %q)", passgen.DefaultSep))
+	cmd.Flags().Bool(CopyFlag, false, "Copy the generated secret to the clipboard")
+
+	_ = cmd.RegisterFlagCompletionFunc(ClassesFlag, cobra.FixedCompletions(passgen.Classes, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// GeneratorOptions returns the policy's generator options with the
+// generator flags that were set applied over them. Flags cannot go below the
+// length or word count required by the policy.
+func GeneratorOptions(cmd *cobra.Command, policy environment.PasswordPolicy) (passgen.Options, error) {
+	flags := cmd.Flags()
+	opts := policy.Options()
+
+	if flags.Changed(LengthFlag) {
+		opts.Length, _ = flags.GetInt(LengthFlag)
+		if opts.Length < policy.Length {
+			return passgen.Options{}, fmt.Errorf("the environment's password policy requires at least %d characters", policy.Length)
+		}
+	}
+	if flags.Changed(ClassesFlag) {
+		opts.Classes, _ = flags.GetStringSlice(ClassesFlag)
+	}
+	if flags.Changed(ExcludeAmbiguousFlag) {
+		opts.ExcludeAmbiguous, _ = flags.GetBool(ExcludeAmbiguousFlag)
+	}
+	if flags.Changed(PassphraseFlag) {
+		opts.Passphrase, _ = flags.GetBool(PassphraseFlag)
+	}
+	if flags.Changed(WordsFlag) {
+		opts.Words, _ = flags.GetInt(WordsFlag)
+		if opts.Words < policy.Words {
+			return passgen.Options{}, fmt.Errorf("the environment's password policy requires at least %d words", policy.Words)
+		}
+	}
+	if flags.Changed(SeparatorFlag) {
+		opts.Separator, _ = flags.GetString(SeparatorFlag)
+	}
+
+	return opts, nil
+}
+
+// GenerateSecret generates a password or passphrase following the password
+// policy of the named environment. Environments that are not registered
+// have no policy.
+func GenerateSecret(cmd *cobra.Command, envName string) (string, error) {
+	var policy environment.PasswordPolicy
+	if envName != "" {
+		service, err := NewEnvironmentService(cmd)
+		if err != nil {
+			return "", err
+		}
+
+		env, err := service.GetEnvironment(envName)
+		if err != nil && !errors.Is(err, environment.ErrEnvironmentNotFound) {
+			return "", err
+		}
+		policy = env.PasswordPolicy
+	}
+
+	opts, err := GeneratorOptions(cmd, policy)
+	if err != nil {
+		return "", err
+	}
+
+	return passgen.Generate(opts)
+}
+
+// CheckGeneratorFlags fails if a generator flag was set on a command where
+// enable, such as --generate, was not, or if --copy was set without a
+// clipboard to copy to.
+func CheckGeneratorFlags(cmd *cobra.Command, enable string) error {
+	if enabled, _ := cmd.Flags().GetBool(enable); enabled {
+		if toClipboard, _ := cmd.Flags().GetBool(CopyFlag); toClipboard {
+			return clipboard.Available()
+		}
+		return nil
+	}
+
+	for _, name := range []string{LengthFlag, ClassesFlag, ExcludeAmbiguousFlag, PassphraseFlag, WordsFlag, SeparatorFlag, CopyFlag} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s requires --%s", name, enable)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmdutil
+
+import (
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/passgen"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGeneratorCommand(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().Bool("generate", false, "")
+	AddGeneratorFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(args))
+
+	return cmd
+}
+
+func TestGeneratorOptions(t *testing.T) {
+	policy := environment.PasswordPolicy{Length: 32, Classes: []string{passgen.ClassLower}, Words: 7}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    passgen.Options
+		wantErr string
+	}{
+		{
+			name: "policy is the default",
+			want: passgen.Options{Length: 32, Classes: []string{passgen.ClassLower}, Words: 7},
+		},
+		{
+			name: "flags override the policy",
+			args: []string{"--length", "40", "--classes", "lower,digits", "--exclude-ambiguous", "--passphrase", "--words", "8", "--separator", "."},
+			want: passgen.Options{
+				Length:           40,
+				Classes:          []string{passgen.ClassLower, passgen.ClassDigits},
+				ExcludeAmbiguous: true,
+				Passphrase:       true,
+				Words:            8,
+				Separator:        ".",
+			},
+		},
+		{
+			name:    "length below the policy",
+			args:    []string{"--length", "16"},
+			wantErr: "at least 32 characters",
+		},
+		{
+			name:    "words below the policy",
+			args:    []string{"--words", "5"},
+			wantErr: "at least 7 words",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GeneratorOptions(newGeneratorCommand(t, tt.args...), policy)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckGeneratorFlags(t *testing.T) {
+	assert.NoError(t, CheckGeneratorFlags(newGeneratorCommand(t), "generate"))
+	assert.NoError(t, CheckGeneratorFlags(newGeneratorCommand(t, "--generate", "--length", "30"), "generate"))
+	assert.EqualError(t, CheckGeneratorFlags(newGeneratorCommand(t, "--copy"), "generate"), "--copy requires --generate")
+}
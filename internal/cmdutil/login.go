@@ -0,0 +1,170 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/login"
+	"jpellissari/dwing/internal/secretinput"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlowFlag              = "flow"
+	AuthorizeURLFlag      = "authorize-url"
+	DeviceURLFlag         = "device-url"
+	TokenURLFlag          = "token-url"
+	ClientIDFlag          = "client-id"
+	ClientSecretStdinFlag = "client-secret-stdin"
+	ClientSecretFileFlag  = "client-secret-file"
+	ClientSecretCmdFlag   = "client-secret-cmd"
+	ScopeFlag             = "scope"
+	OTPFieldFlag          = "otp-field"
+)
+
+// AddLoginFlags registers the flags that override the login settings of the
+// credential's environment. Like passwords, the client secret cannot be
+// passed as an argument, so it never shows up in process listings or the
+// shell history.
+func AddLoginFlags(cmd *cobra.Command) {
+	cmd.Flags().String(FlowFlag, login.FlowPassword, "Login flow to run: "+strings.Join(login.Flows, ", "))
+	cmd.Flags().String(AuthorizeURLFlag, "", "OAuth2 authorization endpoint, for the authorization_code flow")
+	cmd.Flags().String(DeviceURLFlag, "", "OAuth2 device authorization endpoint, for the device_code flow")
+	cmd.Flags().String(TokenURLFlag, "", "OAuth2 token endpoint")
+	cmd.Flags().String(ClientIDFlag, "", "OAuth2 client ID")
+	cmd.Flags().Bool(ClientSecretStdinFlag, false, "Read the OAuth2 client secret from standard input")
+	cmd.Flags().String(ClientSecretFileFlag, "", "Read the OAuth2 client secret from a file")
+	cmd.Flags().String(ClientSecretCmdFlag, "", "Read the OAuth2 client secret from the output of a shell command")
+	cmd.Flags().StringSlice(ScopeFlag, nil, "OAuth2 scopes to request")
+	cmd.Flags().String(OTPFieldFlag, "", "Send the credential's TOTP code in this form field")
+
+	cmd.MarkFlagsMutuallyExclusive(ClientSecretStdinFlag, ClientSecretFileFlag, ClientSecretCmdFlag)
+
+	_ = cmd.RegisterFlagCompletionFunc(FlowFlag, cobra.FixedCompletions(login.Flows, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// LoginSettings starts from the environment's login settings and applies the
+// login flags that were set explicitly on top.
+func LoginSettings(cmd *cobra.Command, env environment.Environment) (login.Settings, error) {
+	flags := cmd.Flags()
+	settings := login.Settings{
+		Flow:         env.Login.Flow,
+		AuthorizeURL: env.Login.AuthorizeURL,
+		DeviceURL:    env.Login.DeviceURL,
+		TokenURL:     env.Login.TokenURL,
+		ClientID:     env.Login.ClientID,
+		Scopes:       env.Login.Scopes,
+		OTPField:     env.Login.OTPField,
+		Recipe:       env.Login.Recipe,
+		BaseURL:      env.BaseURL,
+	}
+
+	if flags.Changed(FlowFlag) {
+		settings.Flow, _ = flags.GetString(FlowFlag)
+	}
+	if flags.Changed(AuthorizeURLFlag) {
+		settings.AuthorizeURL, _ = flags.GetString(AuthorizeURLFlag)
+	}
+	if flags.Changed(DeviceURLFlag) {
+		settings.DeviceURL, _ = flags.GetString(DeviceURLFlag)
+	}
+	if flags.Changed(TokenURLFlag) {
+		settings.TokenURL, _ = flags.GetString(TokenURLFlag)
+	}
+	if flags.Changed(ClientIDFlag) {
+		settings.ClientID, _ = flags.GetString(ClientIDFlag)
+	}
+	if flags.Changed(ScopeFlag) {
+		settings.Scopes, _ = flags.GetStringSlice(ScopeFlag)
+	}
+	if flags.Changed(OTPFieldFlag) {
+		settings.OTPField, _ = flags.GetString(OTPFieldFlag)
+	}
+
+	secret, err := readClientSecret(cmd)
+	if err != nil {
+		return login.Settings{}, err
+	}
+	settings.ClientSecret = secret
+
+	return settings, nil
+}
+
+// readClientSecret returns the client secret from whichever client secret
+// flag was set, or an empty one when none was.
+func readClientSecret(cmd *cobra.Command) (string, error) {
+	flags := cmd.Flags()
+
+	var secret string
+	var err error
+
+	switch {
+	case flags.Changed(ClientSecretStdinFlag):
+		secret, err = secretinput.Read(cmd.InOrStdin())
+	case flags.Changed(ClientSecretFileFlag):
+		path, _ := flags.GetString(ClientSecretFileFlag)
+		secret, err = secretinput.ReadFile(path)
+	case flags.Changed(ClientSecretCmdFlag):
+		command, _ := flags.GetString(ClientSecretCmdFlag)
+		secret, err = secretinput.ReadCommand(cmd.Context(), command)
+	default:
+		return "", nil
+	}
+
+	if err != nil {
+		if errors.Is(err, secretinput.ErrEmpty) {
+			return "", errors.New("client secret cannot be empty")
+		}
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// NewLoginFlow returns the login flow for cred, configured by its
+// environment and the login flags. Interactive flows print their URLs and
+// codes on standard error, the authorization code flow also opens its URL in
+// a browser.
+func NewLoginFlow(cmd *cobra.Command, cred auth.Credential) (login.Flow, error) {
+	envService, err := NewEnvironmentService(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := envService.GetEnvironment(cred.Environment)
+	if err != nil && !errors.Is(err, environment.ErrEnvironmentNotFound) {
+		return nil, fmt.Errorf("failed to get environment: %w", err)
+	}
+
+	settings, err := LoginSettings(cmd, env)
+	if err != nil {
+		return nil, err
+	}
+	settings.OpenURL = OpenURL(cmd)
+	settings.ShowCode = ShowDeviceCode(cmd)
+
+	return login.NewFlowFor(settings, cred)
+}
+
+// ShowDeviceCode returns a function that prints the URL and code to approve
+// a device flow login with on the command's standard error. The browser is
+// not opened, the device flow is meant for sessions without one.
+func ShowDeviceCode(cmd *cobra.Command) func(code login.DeviceCode) error {
+	return func(code login.DeviceCode) error {
+		w := cmd.ErrOrStderr()
+		if _, err := fmt.Fprintf(w, "To log in, open %s and enter the code %s\n", code.VerificationURI, code.UserCode); err != nil {
+			return err
+		}
+		if code.VerificationURIComplete != "" {
+			if _, err := fmt.Fprintf(w, "or open %s\n", code.VerificationURIComplete); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "Waiting for approval, the code expires at %s...\n", code.ExpiresAt.Format(time.Kitchen))
+		return err
+	}
+}
@@ -0,0 +1,118 @@
+package cmdutil
+
+import (
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/login"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginSettings(t *testing.T) {
+	env := environment.Environment{
+		Name: "dev",
+		Login: environment.LoginSettings{
+			Flow:     "password",
+			TokenURL: "https://idp.dev/token",
+			ClientID: "dwing",
+			Scopes:   []string{"openid"},
+			OTPField: "totp",
+		},
+	}
+
+	secretFile := filepath.Join(t.TempDir(), "client-secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("secret\n"), 0600))
+
+	tests := []struct {
+		name  string
+		env   environment.Environment
+		args  []string
+		stdin string
+		want  login.Settings
+	}{
+		{
+			name: "Environment settings are used by default",
+			env:  env,
+			want: login.Settings{
+				Flow:     "password",
+				TokenURL: "https://idp.dev/token",
+				ClientID: "dwing",
+				Scopes:   []string{"openid"},
+				OTPField: "totp",
+			},
+		},
+		{
+			name: "Changed flags override environment settings",
+			env:  env,
+			args: []string{"--token-url", "https://override/token", "--client-secret-file", secretFile, "--otp-field", "otp",
+				"--flow", "authorization_code", "--authorize-url", "https://override/authorize"},
+			want: login.Settings{
+				Flow:         "authorization_code",
+				AuthorizeURL: "https://override/authorize",
+				TokenURL:     "https://override/token",
+				ClientID:     "dwing",
+				ClientSecret: "secret",
+				Scopes:       []string{"openid"},
+				OTPField:     "otp",
+			},
+		},
+		{
+			name:  "Client secret is read from standard input",
+			env:   env,
+			args:  []string{"--client-secret-stdin"},
+			stdin: "from stdin\n",
+			want: login.Settings{
+				Flow:         "password",
+				TokenURL:     "https://idp.dev/token",
+				ClientID:     "dwing",
+				ClientSecret: "from stdin",
+				Scopes:       []string{"openid"},
+				OTPField:     "totp",
+			},
+		},
+		{
+			name: "Unknown environment only uses flags",
+			env:  environment.Environment{},
+			args: []string{"--token-url", "https://flag/token", "--scope", "a,b"},
+			want: login.Settings{
+				TokenURL: "https://flag/token",
+				Scopes:   []string{"a", "b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			AddLoginFlags(cmd)
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			settings, err := LoginSettings(cmd, tt.env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, settings)
+		})
+	}
+
+	t.Run("Empty client secret is rejected", func(t *testing.T) {
+		cmd := &cobra.Command{Use: "test"}
+		AddLoginFlags(cmd)
+		cmd.SetIn(strings.NewReader("\n"))
+		require.NoError(t, cmd.ParseFlags([]string{"--client-secret-stdin"}))
+
+		_, err := LoginSettings(cmd, env)
+		assert.EqualError(t, err, "client secret cannot be empty")
+	})
+
+	t.Run("Client secret is not accepted as an argument", func(t *testing.T) {
+		cmd := &cobra.Command{Use: "test"}
+		AddLoginFlags(cmd)
+
+		assert.Error(t, cmd.ParseFlags([]string{"--client-secret", "secret"}))
+	})
+}
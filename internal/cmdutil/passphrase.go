@@ -0,0 +1,98 @@
+package cmdutil
+
+import (
+	"errors"
+	"os"
+
+	"github.com/charmbracelet/huh"
+)
+
+// PassphraseEnv lets scripts unlock the vault without a prompt.
+const PassphraseEnv = "DWING_PASSPHRASE"
+
+// BundlePassphraseEnv lets scripts export and import encrypted bundles
+// without a prompt. It is separate from PassphraseEnv so a bundle is never
+// silently encrypted with the vault passphrase.
+const BundlePassphraseEnv = "DWING_BUNDLE_PASSPHRASE"
+
+func ReadPassphrase(title string) (string, error) {
+	return readPassphrase(PassphraseEnv, title)
+}
+
+// ReadNewPassphrase asks for a new passphrase twice and makes sure both
+// entries match.
+func ReadNewPassphrase() (string, error) {
+	return readNewPassphrase(PassphraseEnv, "New vault passphrase")
+}
+
+// ReadBundlePassphrase asks for the passphrase of an encrypted bundle, twice
+// when the bundle is being created.
+func ReadBundlePassphrase(create bool) (string, error) {
+	if create {
+		return readNewPassphrase(BundlePassphraseEnv, "Bundle passphrase")
+	}
+	return readPassphrase(BundlePassphraseEnv, "Bundle passphrase")
+}
+
+func readPassphrase(env, title string) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+
+	var passphrase string
+	err := huh.NewInput().
+		Title(title).
+		Prompt(">").
+		EchoMode(huh.EchoModePassword).
+		Value(&passphrase).
+		Run()
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase == "" {
+		return "", errors.New("passphrase cannot be empty")
+	}
+
+	return passphrase, nil
+}
+
+func readNewPassphrase(env, title string) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+
+	var passphrase, confirmation string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(title).
+				Prompt(">").
+				EchoMode(huh.EchoModePassword).
+				Value(&passphrase).
+				Validate(func(s string) error {
+					if s == "" {
+						return errors.New("passphrase cannot be empty")
+					}
+					return nil
+				}),
+			huh.NewInput().
+				Title("Confirm passphrase").
+				Prompt(">").
+				EchoMode(huh.EchoModePassword).
+				Value(&confirmation).
+				Validate(func(s string) error {
+					if s != passphrase {
+						return errors.New("passphrases do not match")
+					}
+					return nil
+				}),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return "", err
+	}
+
+	return passphrase, nil
+}
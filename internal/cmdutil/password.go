@@ -0,0 +1,98 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/config"
+	"jpellissari/dwing/internal/secretinput"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	PasswordFlag      = "password"
+	PasswordStdinFlag = "password-stdin"
+	PasswordFileFlag  = "password-file"
+	PasswordCmdFlag   = "password-cmd"
+	PasswordFDFlag    = "password-fd"
+)
+
+// AddPasswordFlags registers the flags that read a password without putting
+// it in the process arguments. The command must already define --password.
+func AddPasswordFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(PasswordStdinFlag, false, "Read the password from standard input")
+	cmd.Flags().String(PasswordFileFlag, "", "Read the password from a file")
+	cmd.Flags().String(PasswordCmdFlag, "", "Read the password from the output of a shell command")
+	cmd.Flags().Int(PasswordFDFlag, -1, "Read the password from an open file descriptor")
+
+	cmd.MarkFlagsMutuallyExclusive(PasswordFlag, PasswordStdinFlag, PasswordFileFlag, PasswordCmdFlag, PasswordFDFlag)
+}
+
+// PasswordFlagsChanged reports whether the password was given with any of
+// the password flags.
+func PasswordFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range []string{PasswordFlag, PasswordStdinFlag, PasswordFileFlag, PasswordCmdFlag, PasswordFDFlag} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadPassword returns the password from whichever password flag was set and
+// whether one was set at all. A password passed with --password is subject
+// to the password_flag policy from the config.
+func ReadPassword(cmd *cobra.Command) (string, bool, error) {
+	flags := cmd.Flags()
+
+	var password string
+	var err error
+
+	switch {
+	case flags.Changed(PasswordFlag):
+		if err := checkPasswordFlagPolicy(cmd); err != nil {
+			return "", false, err
+		}
+		password, err = flags.GetString(PasswordFlag)
+	case flags.Changed(PasswordStdinFlag):
+		password, err = secretinput.Read(cmd.InOrStdin())
+	case flags.Changed(PasswordFileFlag):
+		path, _ := flags.GetString(PasswordFileFlag)
+		password, err = secretinput.ReadFile(path)
+	case flags.Changed(PasswordCmdFlag):
+		command, _ := flags.GetString(PasswordCmdFlag)
+		password, err = secretinput.ReadCommand(cmd.Context(), command)
+	case flags.Changed(PasswordFDFlag):
+		fd, _ := flags.GetInt(PasswordFDFlag)
+		password, err = secretinput.ReadFD(fd)
+	default:
+		return "", false, nil
+	}
+
+	if err != nil {
+		if errors.Is(err, secretinput.ErrEmpty) {
+			return "", false, errors.New("password cannot be empty")
+		}
+		return "", false, err
+	}
+
+	return password, true, nil
+}
+
+func checkPasswordFlagPolicy(cmd *cobra.Command) error {
+	policy := config.PasswordFlagWarn
+	if cfg, err := Config(cmd); err == nil && cfg.PasswordFlag != "" {
+		policy = cfg.PasswordFlag
+	}
+
+	switch policy {
+	case config.PasswordFlagDeny:
+		return fmt.Errorf("--%s is disabled by the password_flag setting, use --%s, --%s, --%s or --%s instead",
+			PasswordFlag, PasswordStdinFlag, PasswordFileFlag, PasswordCmdFlag, PasswordFDFlag)
+	case config.PasswordFlagWarn:
+		fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  --%s exposes the password in process listings and shell history, prefer --%s\n",
+			PasswordFlag, PasswordStdinFlag)
+	}
+
+	return nil
+}
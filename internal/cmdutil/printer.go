@@ -0,0 +1,38 @@
+package cmdutil
+
+import (
+	"jpellissari/dwing/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	OutputFlag      = "output"
+	ShowSecretsFlag = "show-secrets"
+)
+
+// AddOutputFlags registers the global --output and --show-secrets flags.
+func AddOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP(OutputFlag, "o", output.FormatTable, "Output format: table, json, yaml, jsonpath=<template> or go-template=<template>")
+	cmd.PersistentFlags().Bool(ShowSecretsFlag, false, "Show secrets instead of redacting them in the output")
+}
+
+// NewPrinter returns a printer configured from the global output flags,
+// falling back to the output format from the config file.
+func NewPrinter(cmd *cobra.Command) (*output.Printer, error) {
+	value, _ := cmd.Flags().GetString(OutputFlag)
+	showSecrets, _ := cmd.Flags().GetBool(ShowSecretsFlag)
+
+	if !cmd.Flags().Changed(OutputFlag) {
+		if cfg, err := Config(cmd); err == nil && cfg.Output != "" {
+			value = cfg.Output
+		}
+	}
+
+	format, err := output.ParseFormat(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.NewPrinter(format, showSecrets, cmd.OutOrStdout()), nil
+}
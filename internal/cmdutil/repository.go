@@ -0,0 +1,84 @@
+package cmdutil
+
+import (
+	"errors"
+	"jpellissari/dwing/internal/agent"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCredentialService returns a credential service backed by the configured
+// credential store.
+func NewCredentialService(cmd *cobra.Command) (*auth.CredentialService, error) {
+	cfg, err := Config(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := NewCredentialRepository(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewCredentialService(repo), nil
+}
+
+// NewCredentialRepository opens the credential store for the configured
+// backend. With the auto backend, encrypted vaults are served by the agent
+// when it is running, otherwise they are unlocked with the passphrase from
+// the environment or an interactive prompt, but only once a secret is
+// needed. Plaintext stores are opened as is.
+func NewCredentialRepository(cfg *config.Config) (auth.CredentialRepository, error) {
+	switch cfg.Backend {
+	case config.BackendFile:
+		return auth.NewJSONRepository(cfg.CredentialsPath), nil
+	case config.BackendAgent:
+		client := runningAgent(cfg)
+		if client == nil {
+			return nil, errors.New("agent is not running for this vault, start it with 'dwing agent start'")
+		}
+		return client, nil
+	case config.BackendEncrypted:
+		return openVault(cfg)
+	}
+
+	encrypted, err := auth.IsVault(cfg.CredentialsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !encrypted {
+		return auth.NewJSONRepository(cfg.CredentialsPath), nil
+	}
+
+	return openVault(cfg)
+}
+
+func openVault(cfg *config.Config) (auth.CredentialRepository, error) {
+	if client := runningAgent(cfg); client != nil {
+		return client, nil
+	}
+
+	return auth.NewEncryptedRepositoryFunc(cfg.CredentialsPath, func() (string, error) {
+		return ReadPassphrase("Vault passphrase")
+	}), nil
+}
+
+// runningAgent returns a client of the agent when it is running and serves
+// the configured vault. An agent started for another vault is ignored.
+func runningAgent(cfg *config.Config) *agent.Client {
+	socketPath, err := agent.DefaultSocketPath()
+	if err != nil {
+		return nil
+	}
+
+	client := agent.NewClient(socketPath)
+	status, err := client.Status()
+	if err != nil || !status.Serves(cfg.CredentialsPath) {
+		return nil
+	}
+
+	return client
+}
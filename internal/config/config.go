@@ -4,19 +4,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+const (
+	BackendAuto      = "auto"
+	BackendFile      = "file"
+	BackendEncrypted = "encrypted"
+	BackendAgent     = "agent"
+)
+
+var Backends = []string{BackendAuto, BackendFile, BackendEncrypted, BackendAgent}
+
+// Policies for passing secrets on the command line with --password.
+const (
+	PasswordFlagAllow = "allow"
+	PasswordFlagWarn  = "warn"
+	PasswordFlagDeny  = "deny"
+)
+
+var PasswordFlagPolicies = []string{PasswordFlagAllow, PasswordFlagWarn, PasswordFlagDeny}
+
+// DefaultClipboardTimeout is how long copied secrets stay on the clipboard.
+const DefaultClipboardTimeout = 45 * time.Second
+
 type Config struct {
-	CredentialsPath string `json:"credentials_path"`
+	CredentialsPath    string `json:"credentials_path" yaml:"credentials_path,omitempty"`
+	DefaultEnvironment string `json:"default_environment" yaml:"default_environment,omitempty"`
+	Output             string `json:"output" yaml:"output,omitempty"`
+	Backend            string `json:"backend" yaml:"backend,omitempty"`
+	PasswordFlag       string `json:"password_flag" yaml:"password_flag,omitempty"`
+	ClipboardTimeout   string `json:"clipboard_timeout" yaml:"clipboard_timeout,omitempty"`
 }
 
 func NewDefaultConfig() (*Config, error) {
-	homeDir, err := os.UserHomeDir()
+	credentialsPath, err := defaultCredentialsPath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
 
-	return NewConfig(filepath.Join(homeDir, ".dwing", "credentials.json"))
+	return NewConfig(credentialsPath)
 
 }
 
@@ -38,6 +66,15 @@ func NewConfig(credentialsPath string) (*Config, error) {
 	return cfg, nil
 }
 
+func defaultCredentialsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".dwing", "credentials.json"), nil
+}
+
 func (c *Config) Validate() error {
 	if c.CredentialsPath == "" {
 		return fmt.Errorf("credentials path cannot be empty")
@@ -47,9 +84,34 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("credentials path must be an absolute path: %s", c.CredentialsPath)
 	}
 
+	for _, key := range Keys {
+		if key.validate == nil {
+			continue
+		}
+		if value := key.get(c); value != "" {
+			if err := key.validate(value); err != nil {
+				return fmt.Errorf("invalid %s: %w", key.Name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// ClipboardClearAfter returns how long copied secrets stay on the clipboard,
+// zero meaning they are never cleared.
+func (c *Config) ClipboardClearAfter() time.Duration {
+	if c.ClipboardTimeout == "" {
+		return DefaultClipboardTimeout
+	}
+
+	d, err := time.ParseDuration(c.ClipboardTimeout)
+	if err != nil {
+		return DefaultClipboardTimeout
+	}
+	return d
+}
+
 func (c *Config) EnsureCredentialsDirExists() error {
 	dir := filepath.Dir(c.CredentialsPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -57,3 +119,18 @@ func (c *Config) EnsureCredentialsDirExists() error {
 	}
 	return nil
 }
+
+// expandHome turns a leading "~/" into the user's home directory, so paths
+// in the config file can be written the way people type them.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~")), nil
+}
@@ -105,3 +105,134 @@ func TestEnsureCredentialsDirExists(t *testing.T) {
 		assert.True(t, info.IsDir())
 	})
 }
+
+func TestLoad(t *testing.T) {
+	t.Run("Missing file uses defaults", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+
+		cfg, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".dwing", "credentials.json"), cfg.CredentialsPath)
+		assert.Equal(t, BackendAuto, cfg.Backend)
+		assert.Equal(t, PasswordFlagWarn, cfg.PasswordFlag)
+		assert.Equal(t, DefaultClipboardTimeout, cfg.ClipboardClearAfter())
+	})
+
+	t.Run("File values override defaults", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "credentials_path: ~/vault.json\ndefault_environment: dev\noutput: json\nbackend: encrypted\npassword_flag: deny\nclipboard_timeout: 0s\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		cfg, err := Load(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, &Config{
+			CredentialsPath:    filepath.Join(home, "vault.json"),
+			DefaultEnvironment: "dev",
+			Output:             "json",
+			Backend:            BackendEncrypted,
+			PasswordFlag:       PasswordFlagDeny,
+			ClipboardTimeout:   "0s",
+		}, cfg)
+		assert.Zero(t, cfg.ClipboardClearAfter())
+	})
+
+	t.Run("Environment overrides file", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("DWING_BACKEND", BackendFile)
+		t.Setenv("DWING_DEFAULT_ENVIRONMENT", "staging")
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("backend: agent\ndefault_environment: dev\n"), 0644))
+
+		cfg, err := Load(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, BackendFile, cfg.Backend)
+		assert.Equal(t, "staging", cfg.DefaultEnvironment)
+	})
+
+	t.Run("Invalid file value fails", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("backend: cloud\n"), 0644))
+
+		_, err := Load(path)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown backend")
+	})
+
+	t.Run("Invalid environment value fails", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("DWING_OUTPUT", "xml")
+
+		_, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "DWING_OUTPUT")
+	})
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+
+	path, err := DefaultPath()
+
+	require.NoError(t, err)
+	assert.Equal(t, "/xdg/dwing/config.yaml", path)
+}
+
+func TestSetAndWriteFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr string
+	}{
+		{name: "Valid backend", key: "backend", value: "agent"},
+		{name: "Valid output", key: "output", value: "jsonpath={.id}"},
+		{name: "Empty value resets key", key: "default_environment", value: ""},
+		{name: "Unknown key", key: "colour", value: "blue", wantErr: "unknown config key"},
+		{name: "Valid password flag policy", key: "password_flag", value: "deny"},
+		{name: "Invalid backend", key: "backend", value: "cloud", wantErr: "unknown backend"},
+		{name: "Invalid password flag policy", key: "password_flag", value: "never", wantErr: "unknown policy"},
+		{name: "Invalid output", key: "output", value: "xml", wantErr: "unknown output format"},
+		{name: "Valid clipboard timeout", key: "clipboard_timeout", value: "1m30s"},
+		{name: "Invalid clipboard timeout", key: "clipboard_timeout", value: "soon", wantErr: "invalid duration"},
+		{name: "Negative clipboard timeout", key: "clipboard_timeout", value: "-5s", wantErr: "must not be negative"},
+		{name: "Relative credentials path", key: "credentials_path", value: "creds.json", wantErr: "must be an absolute path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{DefaultEnvironment: "dev"}
+
+			err := cfg.Set(tt.key, tt.value)
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			value, err := cfg.Get(tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, value)
+
+			path := filepath.Join(t.TempDir(), "dwing", "config.yaml")
+			require.NoError(t, cfg.WriteFile(path))
+
+			reread, err := ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, cfg, reread)
+		})
+	}
+}
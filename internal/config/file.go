@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath returns $XDG_CONFIG_HOME/dwing/config.yaml, falling back to
+// ~/.config/dwing/config.yaml.
+func DefaultPath() (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "dwing", "config.yaml"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".config", "dwing", "config.yaml"), nil
+}
+
+// Load builds the effective config: defaults, overridden by the config file
+// at path (if it exists), overridden by DWING_* environment variables.
+func Load(path string) (*Config, error) {
+	credentialsPath, err := defaultCredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		CredentialsPath:  credentialsPath,
+		Backend:          BackendAuto,
+		PasswordFlag:     PasswordFlagWarn,
+		ClipboardTimeout: DefaultClipboardTimeout.String(),
+	}
+
+	file, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.merge(file)
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.EnsureCredentialsDirExists(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ReadFile returns only the values set in the config file. A missing file is
+// an empty config.
+func ReadFile(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if cfg.CredentialsPath != "" {
+		cfg.CredentialsPath, err = expandHome(cfg.CredentialsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) merge(other *Config) {
+	for _, key := range Keys {
+		if value := key.get(other); value != "" {
+			key.set(c, value)
+		}
+	}
+}
+
+func (c *Config) applyEnv() error {
+	for _, key := range Keys {
+		value, ok := os.LookupEnv(key.EnvVar())
+		if !ok || value == "" {
+			continue
+		}
+
+		if err := c.Set(key.Name, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", key.EnvVar(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/output"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Key describes a setting that can be read and written with 'dwing config'.
+type Key struct {
+	Name        string
+	Description string
+
+	get      func(c *Config) string
+	set      func(c *Config, value string)
+	validate func(value string) error
+}
+
+var Keys = []Key{
+	{
+		Name:        "credentials_path",
+		Description: "Path of the credential store",
+		get:         func(c *Config) string { return c.CredentialsPath },
+		set:         func(c *Config, value string) { c.CredentialsPath = value },
+		validate: func(value string) error {
+			if !filepath.IsAbs(value) {
+				return fmt.Errorf("must be an absolute path: %s", value)
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "default_environment",
+		Description: "Environment used when none is given",
+		get:         func(c *Config) string { return c.DefaultEnvironment },
+		set:         func(c *Config, value string) { c.DefaultEnvironment = value },
+	},
+	{
+		Name:        "output",
+		Description: "Default output format",
+		get:         func(c *Config) string { return c.Output },
+		set:         func(c *Config, value string) { c.Output = value },
+		validate: func(value string) error {
+			_, err := output.ParseFormat(value)
+			return err
+		},
+	},
+	{
+		Name:        "backend",
+		Description: "Credential store backend: " + strings.Join(Backends, ", "),
+		get:         func(c *Config) string { return c.Backend },
+		set:         func(c *Config, value string) { c.Backend = value },
+		validate: func(value string) error {
+			if !slices.Contains(Backends, value) {
+				return fmt.Errorf("unknown backend %q, expected one of: %s", value, strings.Join(Backends, ", "))
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "password_flag",
+		Description: "What to do when a secret is passed with --password: " + strings.Join(PasswordFlagPolicies, ", "),
+		get:         func(c *Config) string { return c.PasswordFlag },
+		set:         func(c *Config, value string) { c.PasswordFlag = value },
+		validate: func(value string) error {
+			if !slices.Contains(PasswordFlagPolicies, value) {
+				return fmt.Errorf("unknown policy %q, expected one of: %s", value, strings.Join(PasswordFlagPolicies, ", "))
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "clipboard_timeout",
+		Description: "How long copied secrets stay on the clipboard, e.g. 30s, 0 to keep them",
+		get:         func(c *Config) string { return c.ClipboardTimeout },
+		set:         func(c *Config, value string) { c.ClipboardTimeout = value },
+		validate: func(value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			if d < 0 {
+				return fmt.Errorf("must not be negative: %s", value)
+			}
+			return nil
+		},
+	},
+}
+
+func LookupKey(name string) (Key, error) {
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+
+	names := make([]string, 0, len(Keys))
+	for _, key := range Keys {
+		names = append(names, key.Name)
+	}
+
+	return Key{}, fmt.Errorf("unknown config key %q, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// EnvVar is the environment variable overriding the key, e.g.
+// DWING_CREDENTIALS_PATH.
+func (k Key) EnvVar() string {
+	return "DWING_" + strings.ToUpper(k.Name)
+}
+
+func (c *Config) Get(name string) (string, error) {
+	key, err := LookupKey(name)
+	if err != nil {
+		return "", err
+	}
+
+	return key.get(c), nil
+}
+
+// Set validates value and assigns it to the key. An empty value resets the
+// key to its default.
+func (c *Config) Set(name, value string) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+
+	if name == "credentials_path" && value != "" {
+		value, err = expandHome(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if value != "" && key.validate != nil {
+		if err := key.validate(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	key.set(c, value)
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/passgen"
+	"jpellissari/dwing/internal/recipe"
+	"slices"
+	"strings"
+)
+
+const (
+	DangerLow    = "low"
+	DangerMedium = "medium"
+	DangerHigh   = "high"
+)
+
+var DangerLevels = []string{DangerLow, DangerMedium, DangerHigh}
+
+// LoginSettings describe how to obtain a token for credentials of the
+// environment.
+type LoginSettings struct {
+	Flow         string   `json:"flow,omitempty"`
+	AuthorizeURL string   `json:"authorize_url,omitempty"`
+	DeviceURL    string   `json:"device_url,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	OTPField     string   `json:"otp_field,omitempty"`
+	// JWKSURL is the URL or file of the key set that signs the tokens.
+	JWKSURL string `json:"jwks_url,omitempty"`
+	// Recipe logs in to APIs with a bespoke authentication endpoint.
+	Recipe *recipe.Recipe `json:"recipe,omitempty"`
+}
+
+// PasswordPolicy describes how secrets are generated for credentials of the
+// environment. Zero values use the generator defaults. Length and Words are
+// also the minimum a generated secret may have.
+type PasswordPolicy struct {
+	Length           int      `json:"length,omitempty"`
+	Classes          []string `json:"classes,omitempty"`
+	ExcludeAmbiguous bool     `json:"exclude_ambiguous,omitempty"`
+	Passphrase       bool     `json:"passphrase,omitempty"`
+	Words            int      `json:"words,omitempty"`
+	Separator        string   `json:"separator,omitempty"`
+}
+
+// Options returns the generator options of the policy.
+func (p PasswordPolicy) Options() passgen.Options {
+	return passgen.Options{
+		Length:           p.Length,
+		Classes:          p.Classes,
+		ExcludeAmbiguous: p.ExcludeAmbiguous,
+		Passphrase:       p.Passphrase,
+		Words:            p.Words,
+		Separator:        p.Separator,
+	}
+}
+
+func (p PasswordPolicy) Validate() error {
+	if p.Length != 0 && p.Length < passgen.MinLength {
+		return fmt.Errorf("password policy length must be at least %d", passgen.MinLength)
+	}
+	if p.Words != 0 && p.Words < passgen.MinWords {
+		return fmt.Errorf("password policy words must be at least %d", passgen.MinWords)
+	}
+	return passgen.ValidateClasses(p.Classes)
+}
+
+type Environment struct {
+	Name           string         `json:"name"`
+	Aliases        []string       `json:"aliases,omitempty"`
+	BaseURL        string         `json:"base_url,omitempty"`
+	DangerLevel    string         `json:"danger_level"`
+	Login          LoginSettings  `json:"login,omitzero"`
+	PasswordPolicy PasswordPolicy `json:"password_policy,omitzero"`
+}
+
+func (e *Environment) Validate() error {
+	if e.Name == "" {
+		return errors.New("name is required")
+	}
+
+	for _, name := range e.Names() {
+		if strings.ContainsAny(name, "/ \t") {
+			return fmt.Errorf("name %q cannot contain slashes or spaces", name)
+		}
+	}
+
+	if e.DangerLevel != "" && !slices.Contains(DangerLevels, e.DangerLevel) {
+		return fmt.Errorf("unknown danger level %q, expected one of: %s", e.DangerLevel, strings.Join(DangerLevels, ", "))
+	}
+
+	if e.Login.Recipe != nil {
+		if err := e.Login.Recipe.Validate(); err != nil {
+			return fmt.Errorf("invalid login recipe: %w", err)
+		}
+	}
+
+	return e.PasswordPolicy.Validate()
+}
+
+// Names returns the name followed by every alias.
+func (e *Environment) Names() []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+// Matches reports whether name refers to the environment. Names and aliases
+// are compared case-insensitively, so "Prod" and "prod" are the same
+// environment.
+func (e *Environment) Matches(name string) bool {
+	for _, n := range e.Names() {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Environment) IsDangerous() bool {
+	return e.DangerLevel == DangerHigh
+}
+
+type Environments []Environment
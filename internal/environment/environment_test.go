@@ -0,0 +1,50 @@
+package environment_test
+
+import (
+	"jpellissari/dwing/internal/environment"
+	"jpellissari/dwing/internal/recipe"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEnvironment(t *testing.T) {
+	testCases := []struct {
+		name       string
+		env        environment.Environment
+		shouldFail bool
+	}{
+		{name: "valid_environment", env: environment.Environment{Name: "prod", Aliases: []string{"production"}, DangerLevel: "high"}},
+		{name: "empty_danger_level_is_valid", env: environment.Environment{Name: "dev"}},
+		{name: "missing_name", env: environment.Environment{DangerLevel: "low"}, shouldFail: true},
+		{name: "slash_in_name", env: environment.Environment{Name: "prod/eu"}, shouldFail: true},
+		{name: "space_in_alias", env: environment.Environment{Name: "prod", Aliases: []string{"prod eu"}}, shouldFail: true},
+		{name: "unknown_danger_level", env: environment.Environment{Name: "prod", DangerLevel: "extreme"}, shouldFail: true},
+		{name: "valid_password_policy", env: environment.Environment{Name: "prod", PasswordPolicy: environment.PasswordPolicy{Length: 32, Classes: []string{"lower", "digits"}}}},
+		{name: "short_password_policy", env: environment.Environment{Name: "prod", PasswordPolicy: environment.PasswordPolicy{Length: 6}}, shouldFail: true},
+		{name: "valid_login_recipe", env: environment.Environment{Name: "prod", Login: environment.LoginSettings{Recipe: &recipe.Recipe{Steps: []recipe.Step{{URL: "http://x"}}, Token: recipe.TokenSpec{AccessToken: "t"}}}}},
+		{name: "empty_login_recipe", env: environment.Environment{Name: "prod", Login: environment.LoginSettings{Recipe: &recipe.Recipe{}}}, shouldFail: true},
+		{name: "unknown_password_class", env: environment.Environment{Name: "prod", PasswordPolicy: environment.PasswordPolicy{Classes: []string{"emoji"}}}, shouldFail: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.env.Validate()
+			if tc.shouldFail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	env := environment.Environment{Name: "prod", Aliases: []string{"production", "prd"}}
+
+	assert.True(t, env.Matches("prod"))
+	assert.True(t, env.Matches("Prod"))
+	assert.True(t, env.Matches("PRODUCTION"))
+	assert.True(t, env.Matches("prd"))
+	assert.False(t, env.Matches("staging"))
+}
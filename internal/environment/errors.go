@@ -0,0 +1,7 @@
+package environment
+
+import "errors"
+
+var (
+	ErrEnvironmentNotFound = errors.New("environment not found")
+)
@@ -0,0 +1,99 @@
+package environment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/fsutil"
+	"os"
+	"path/filepath"
+)
+
+type Repository interface {
+	GetAll() (Environments, error)
+	Add(env Environment) error
+	RemoveByName(name string) error
+}
+
+// JSONRepository stores environments in a plain JSON file. Environments hold
+// no secrets, so the file is never encrypted.
+type JSONRepository struct {
+	filePath string
+}
+
+func NewJSONRepository(filePath string) *JSONRepository {
+	return &JSONRepository{filePath: filePath}
+}
+
+// PathFor returns the environments file stored next to the credentials file.
+func PathFor(credentialsPath string) string {
+	return filepath.Join(filepath.Dir(credentialsPath), "environments.json")
+}
+
+func (r *JSONRepository) GetAll() (Environments, error) {
+	data, err := os.ReadFile(r.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Environments{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if len(data) == 0 {
+		return Environments{}, nil
+	}
+
+	var envs Environments
+	if err := json.Unmarshal(data, &envs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	return envs, nil
+}
+
+func (r *JSONRepository) Add(env Environment) error {
+	return r.mutate(func(envs Environments) (Environments, error) {
+		return append(envs, env), nil
+	})
+}
+
+func (r *JSONRepository) RemoveByName(name string) error {
+	return r.mutate(func(envs Environments) (Environments, error) {
+		for i, e := range envs {
+			if e.Name == name {
+				return append(envs[:i], envs[i+1:]...), nil
+			}
+		}
+
+		return nil, ErrEnvironmentNotFound
+	})
+}
+
+func (r *JSONRepository) mutate(fn func(Environments) (Environments, error)) error {
+	unlock, err := fsutil.LockDir(r.filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	envs, err := r.GetAll()
+	if err != nil {
+		return err
+	}
+
+	envs, err = fn(envs)
+	if err != nil {
+		return err
+	}
+
+	if envs == nil {
+		envs = Environments{}
+	}
+
+	data, err := json.MarshalIndent(envs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	return fsutil.WriteFileAtomic(r.filePath, data, 0644)
+}
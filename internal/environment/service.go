@@ -0,0 +1,95 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) ListEnvironments() (Environments, error) {
+	return s.repo.GetAll()
+}
+
+func (s *Service) AddEnvironment(env Environment) error {
+	if env.DangerLevel == "" {
+		env.DangerLevel = DangerLow
+	}
+
+	if err := env.Validate(); err != nil {
+		return fmt.Errorf("invalid environment: %w", err)
+	}
+
+	envs, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range envs {
+		for _, name := range env.Names() {
+			if existing.Matches(name) {
+				return fmt.Errorf("'%s' is already used by environment '%s'", name, existing.Name)
+			}
+		}
+	}
+
+	return s.repo.Add(env)
+}
+
+func (s *Service) RemoveEnvironment(name string) error {
+	env, err := s.GetEnvironment(name)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RemoveByName(env.Name)
+}
+
+// GetEnvironment finds an environment by name or alias.
+func (s *Service) GetEnvironment(name string) (Environment, error) {
+	envs, err := s.repo.GetAll()
+	if err != nil {
+		return Environment{}, err
+	}
+
+	for _, env := range envs {
+		if env.Matches(name) {
+			return env, nil
+		}
+	}
+
+	return Environment{}, ErrEnvironmentNotFound
+}
+
+// Canonicalize maps a name or alias to the environment's canonical name.
+// While no environment is registered any name is accepted as is, so
+// credential stores created before environments existed keep working.
+func (s *Service) Canonicalize(name string) (string, error) {
+	envs, err := s.repo.GetAll()
+	if err != nil {
+		return "", err
+	}
+
+	if len(envs) == 0 {
+		return name, nil
+	}
+
+	for _, env := range envs {
+		if env.Matches(name) {
+			return env.Name, nil
+		}
+	}
+
+	known := make([]string, 0, len(envs))
+	for _, env := range envs {
+		known = append(known, env.Name)
+	}
+
+	return "", fmt.Errorf("%w: '%s', known environments are: %s", ErrEnvironmentNotFound, name, strings.Join(known, ", "))
+}
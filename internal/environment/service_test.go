@@ -0,0 +1,98 @@
+package environment_test
+
+import (
+	"jpellissari/dwing/internal/environment"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newService(t *testing.T, envs ...environment.Environment) *environment.Service {
+	t.Helper()
+
+	repo := environment.NewJSONRepository(filepath.Join(t.TempDir(), "environments.json"))
+	service := environment.NewService(repo)
+	for _, env := range envs {
+		require.NoError(t, service.AddEnvironment(env))
+	}
+
+	return service
+}
+
+func TestAddEnvironment(t *testing.T) {
+	prod := environment.Environment{Name: "prod", Aliases: []string{"production"}, DangerLevel: "high"}
+
+	testCases := []struct {
+		name        string
+		env         environment.Environment
+		errContains string
+	}{
+		{name: "new environment", env: environment.Environment{Name: "dev"}},
+		{name: "name clashes with existing name", env: environment.Environment{Name: "Prod"}, errContains: "already used by environment 'prod'"},
+		{name: "alias clashes with existing alias", env: environment.Environment{Name: "live", Aliases: []string{"production"}}, errContains: "already used"},
+		{name: "invalid environment", env: environment.Environment{Name: "a/b"}, errContains: "invalid environment"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := newService(t, prod)
+
+			err := service.AddEnvironment(tc.env)
+
+			if tc.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			envs, err := service.ListEnvironments()
+			require.NoError(t, err)
+			assert.Len(t, envs, 2)
+			assert.Equal(t, "low", envs[1].DangerLevel, "danger level defaults to low")
+		})
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	t.Run("any name is accepted without environments", func(t *testing.T) {
+		service := newService(t)
+
+		name, err := service.Canonicalize("whatever")
+
+		require.NoError(t, err)
+		assert.Equal(t, "whatever", name)
+	})
+
+	t.Run("aliases map to the canonical name", func(t *testing.T) {
+		service := newService(t, environment.Environment{Name: "prod", Aliases: []string{"production"}})
+
+		name, err := service.Canonicalize("Production")
+
+		require.NoError(t, err)
+		assert.Equal(t, "prod", name)
+	})
+
+	t.Run("unknown names are rejected", func(t *testing.T) {
+		service := newService(t, environment.Environment{Name: "prod"}, environment.Environment{Name: "dev"})
+
+		_, err := service.Canonicalize("staging")
+
+		require.ErrorIs(t, err, environment.ErrEnvironmentNotFound)
+		assert.Contains(t, err.Error(), "prod, dev")
+	})
+}
+
+func TestRemoveEnvironment(t *testing.T) {
+	service := newService(t, environment.Environment{Name: "prod", Aliases: []string{"production"}})
+
+	require.NoError(t, service.RemoveEnvironment("production"))
+
+	envs, err := service.ListEnvironments()
+	require.NoError(t, err)
+	assert.Empty(t, envs)
+
+	assert.ErrorIs(t, service.RemoveEnvironment("prod"), environment.ErrEnvironmentNotFound)
+}
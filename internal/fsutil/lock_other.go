@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package fsutil
+
+// LockFile is a no-op on platforms without advisory file locks. Writes are
+// still atomic, but concurrent writers may lose updates.
+func LockFile(path string) (func() error, error) {
+	return func() error { return nil }, nil
+}
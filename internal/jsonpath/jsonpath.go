@@ -0,0 +1,368 @@
+// Package jsonpath implements the subset of kubectl style JSONPath templates
+// that dwing needs to pull values out of decoded JSON documents.
+//
+// A template mixes plain text with expressions in braces:
+//
+//	{.field.sub}       field access
+//	{.items[0]}        index, negative indexes count from the end
+//	{.items[*].id}     wildcard over slices and maps
+//	{$.root}           path from the document root
+//	{"\n"}             string literal
+//	{range .items[*]}{.id}{"\n"}{end}
+//
+// Expressions that yield several values print them separated by spaces.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type nodeKind int
+
+const (
+	nodeText nodeKind = iota
+	nodePath
+	nodeRange
+)
+
+type node struct {
+	kind     nodeKind
+	text     string
+	path     path
+	children []node
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+type step struct {
+	kind  stepKind
+	field string
+	index int
+}
+
+type path struct {
+	fromRoot bool
+	steps    []step
+}
+
+// Template is a parsed JSONPath template.
+type Template struct {
+	nodes []node
+}
+
+// Parse compiles a template.
+func Parse(text string) (*Template, error) {
+	nodes, rest, err := parseNodes(text, false)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("jsonpath: unexpected text after template: %q", rest)
+	}
+
+	return &Template{nodes: nodes}, nil
+}
+
+func parseNodes(text string, inRange bool) ([]node, string, error) {
+	var nodes []node
+
+	for text != "" {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			nodes = append(nodes, node{kind: nodeText, text: text})
+			return nodes, "", nil
+		}
+		if open > 0 {
+			nodes = append(nodes, node{kind: nodeText, text: text[:open]})
+		}
+
+		end := closingBrace(text[open:])
+		if end < 0 {
+			return nil, "", fmt.Errorf("jsonpath: unclosed expression in %q", text[open:])
+		}
+
+		expr := strings.TrimSpace(text[open+1 : open+end])
+		text = text[open+end+1:]
+
+		switch {
+		case expr == "end":
+			if !inRange {
+				return nil, "", fmt.Errorf("jsonpath: {end} without {range}")
+			}
+			return nodes, text, nil
+		case strings.HasPrefix(expr, "range "):
+			p, err := parsePath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, "", err
+			}
+
+			children, rest, err := parseNodes(text, true)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, node{kind: nodeRange, path: p, children: children})
+			text = rest
+		case strings.HasPrefix(expr, `"`):
+			literal, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, "", fmt.Errorf("jsonpath: invalid string literal %s", expr)
+			}
+			nodes = append(nodes, node{kind: nodeText, text: literal})
+		default:
+			p, err := parsePath(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, node{kind: nodePath, path: p})
+		}
+	}
+
+	if inRange {
+		return nil, "", fmt.Errorf("jsonpath: {range} without {end}")
+	}
+
+	return nodes, "", nil
+}
+
+// closingBrace returns the index of the brace closing the expression that
+// starts at s[0], skipping braces inside string literals.
+func closingBrace(s string) int {
+	inString := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '}':
+			if !inString {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parsePath(expr string) (path, error) {
+	var p path
+
+	switch {
+	case strings.HasPrefix(expr, "$"):
+		p.fromRoot = true
+		expr = expr[1:]
+	case strings.HasPrefix(expr, "@"):
+		expr = expr[1:]
+	}
+
+	for expr != "" {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			if expr == "" {
+				return p, nil
+			}
+			if expr[0] == '[' || expr[0] == '.' {
+				continue
+			}
+
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			name := expr[:end]
+			expr = expr[end:]
+
+			if name == "*" {
+				p.steps = append(p.steps, step{kind: stepWildcard})
+			} else {
+				p.steps = append(p.steps, step{kind: stepField, field: name})
+			}
+		case '[':
+			end := strings.IndexByte(expr, ']')
+			if end < 0 {
+				return path{}, fmt.Errorf("jsonpath: unclosed bracket in %q", expr)
+			}
+			inner := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+
+			switch {
+			case inner == "*":
+				p.steps = append(p.steps, step{kind: stepWildcard})
+			case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+				p.steps = append(p.steps, step{kind: stepField, field: strings.Trim(inner, `'"`)})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return path{}, fmt.Errorf("jsonpath: invalid index %q", inner)
+				}
+				p.steps = append(p.steps, step{kind: stepIndex, index: i})
+			}
+		default:
+			return path{}, fmt.Errorf("jsonpath: unexpected %q in expression", expr)
+		}
+	}
+
+	return p, nil
+}
+
+// Execute evaluates the template against data, which must be made of the
+// types encoding/json decodes into.
+func (t *Template) Execute(data any) (string, error) {
+	var b strings.Builder
+	if err := execNodes(&b, t.nodes, data, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func execNodes(b *strings.Builder, nodes []node, root, current any) error {
+	for _, n := range nodes {
+		switch n.kind {
+		case nodeText:
+			b.WriteString(n.text)
+		case nodePath:
+			values, err := n.path.eval(root, current)
+			if err != nil {
+				return err
+			}
+			for i, v := range values {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				s, err := format(v)
+				if err != nil {
+					return err
+				}
+				b.WriteString(s)
+			}
+		case nodeRange:
+			values, err := n.path.eval(root, current)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				if err := execNodes(b, n.children, root, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup evaluates a single path expression, with or without braces, and
+// returns every value it matches.
+func Lookup(expr string, data any) ([]any, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+
+	p, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.eval(data, data)
+}
+
+func (p path) eval(root, current any) ([]any, error) {
+	values := []any{current}
+	if p.fromRoot {
+		values = []any{root}
+	}
+
+	for _, s := range p.steps {
+		var next []any
+		for _, v := range values {
+			matched, err := s.apply(v)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, matched...)
+		}
+		values = next
+	}
+
+	return values, nil
+}
+
+func (s step) apply(v any) ([]any, error) {
+	switch s.kind {
+	case stepField:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("jsonpath: cannot read field %q of %T", s.field, v)
+		}
+		value, ok := m[s.field]
+		if !ok {
+			return nil, fmt.Errorf("jsonpath: field %q not found", s.field)
+		}
+		return []any{value}, nil
+	case stepIndex:
+		list, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("jsonpath: cannot index %T", v)
+		}
+		i := s.index
+		if i < 0 {
+			i += len(list)
+		}
+		if i < 0 || i >= len(list) {
+			return nil, fmt.Errorf("jsonpath: index %d out of range", s.index)
+		}
+		return []any{list[i]}, nil
+	case stepWildcard:
+		switch value := v.(type) {
+		case []any:
+			return value, nil
+		case map[string]any:
+			keys := make([]string, 0, len(value))
+			for k := range value {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			values := make([]any, 0, len(keys))
+			for _, k := range keys {
+				values = append(values, value[k])
+			}
+			return values, nil
+		default:
+			return nil, fmt.Errorf("jsonpath: cannot iterate over %T", v)
+		}
+	}
+
+	return nil, fmt.Errorf("jsonpath: unknown step")
+}
+
+func format(v any) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,85 @@
+package jsonpath_test
+
+import (
+	"encoding/json"
+	"jpellissari/dwing/internal/jsonpath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `{
+	"items": [
+		{"id": "1", "env": "dev", "tags": {"team": "core"}},
+		{"id": "2", "env": "prod", "tags": {"team": "ops"}}
+	],
+	"count": 2,
+	"data": {"token": {"value": "abc", "expires_in": 3600}}
+}`
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestTemplateExecute(t *testing.T) {
+	data := decode(t, document)
+
+	testCases := []struct {
+		name        string
+		template    string
+		want        string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "field access", template: "{.data.token.value}", want: "abc"},
+		{name: "number formatting", template: "{.data.token.expires_in}", want: "3600"},
+		{name: "index", template: "{.items[1].id}", want: "2"},
+		{name: "negative index", template: "{.items[-1].env}", want: "prod"},
+		{name: "wildcard joins with spaces", template: "{.items[*].id}", want: "1 2"},
+		{name: "bracket field", template: "{.items[0]['env']}", want: "dev"},
+		{name: "text around expressions", template: "count={.count}!", want: "count=2!"},
+		{name: "range with literal", template: `{range .items[*]}{.id}:{.tags.team}{"\n"}{end}`, want: "1:core\n2:ops\n"},
+		{name: "root inside range", template: `{range .items[*]}{$.count}{end}`, want: "22"},
+		{name: "objects are printed as JSON", template: "{.items[0].tags}", want: `{"team":"core"}`},
+		{name: "missing field", template: "{.nope}", wantErr: true, errContains: `field "nope" not found`},
+		{name: "out of range", template: "{.items[5]}", wantErr: true, errContains: "out of range"},
+		{name: "unclosed expression", template: "{.items", wantErr: true, errContains: "unclosed expression"},
+		{name: "range without end", template: "{range .items[*]}{.id}", wantErr: true, errContains: "without {end}"},
+		{name: "end without range", template: "{.count}{end}", wantErr: true, errContains: "without {range}"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := jsonpath.Parse(tc.template)
+			if err == nil {
+				var got string
+				got, err = tmpl.Execute(data)
+				if !tc.wantErr {
+					require.NoError(t, err)
+					assert.Equal(t, tc.want, got)
+					return
+				}
+			}
+
+			require.True(t, tc.wantErr, "unexpected error: %v", err)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errContains)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	data := decode(t, document)
+
+	values, err := jsonpath.Lookup("$.items[*].env", data)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"dev", "prod"}, values)
+
+	values, err = jsonpath.Lookup("{.data.token.value}", data)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"abc"}, values)
+}
@@ -0,0 +1,269 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// JWKS is a JSON Web Key Set, as served by an identity provider's jwks_uri.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public key of a key set. Private key members are ignored.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ParseJWKS reads a key set. A single JWK is accepted too.
+func ParseJWKS(data []byte) (JWKS, error) {
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return JWKS{}, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	if set.Keys == nil {
+		var key JWK
+		if err := json.Unmarshal(data, &key); err == nil && key.Kty != "" {
+			set.Keys = []JWK{key}
+		}
+	}
+	if len(set.Keys) == 0 {
+		return JWKS{}, errors.New("JWKS has no keys")
+	}
+
+	return set, nil
+}
+
+// LoadJWKS reads a key set from an http(s) URL or a file.
+func LoadJWKS(ctx context.Context, client *http.Client, location string) (JWKS, error) {
+	if !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return JWKS{}, fmt.Errorf("failed to read JWKS: %w", err)
+		}
+		return ParseJWKS(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("invalid JWKS URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return JWKS{}, fmt.Errorf("failed to fetch JWKS: %s returned %d", location, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return JWKS{}, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// Verify checks the signature of the token with the keys of the set and
+// returns the kid of the key that verified it. The key is picked by the kid
+// of the token header, or else every key of a matching type is tried.
+func (t *Token) Verify(set JWKS) (string, error) {
+	alg := t.Alg()
+	verify, ok := verifiers[alg]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlg, alg)
+	}
+
+	kid := t.KeyID()
+	tried := false
+	for _, key := range set.Keys {
+		if kid != "" && key.Kid != kid {
+			continue
+		}
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.Alg != "" && key.Alg != alg {
+			continue
+		}
+
+		pub, err := key.PublicKey()
+		if err != nil {
+			if kid != "" {
+				return "", fmt.Errorf("key %s: %w", kid, err)
+			}
+			continue
+		}
+
+		if err := verify(pub, []byte(t.signingInput), t.signature); err != nil {
+			if errors.Is(err, errKeyType) {
+				continue
+			}
+			tried = true
+			continue
+		}
+		return key.Kid, nil
+	}
+
+	if tried {
+		return "", ErrInvalidSignature
+	}
+	return "", ErrNoKey
+}
+
+// PublicKey returns the RSA, ECDSA or Ed25519 public key of the JWK.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if err := errors.Join(errX, errY); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC point size")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// errKeyType means the key does not fit the algorithm, the next one of the
+// set is tried.
+var errKeyType = errors.New("key type does not match the algorithm")
+
+type verifier func(pub crypto.PublicKey, input, signature []byte) error
+
+var verifiers = map[string]verifier{
+	"RS256": verifyRSA(crypto.SHA256, false),
+	"RS384": verifyRSA(crypto.SHA384, false),
+	"RS512": verifyRSA(crypto.SHA512, false),
+	"PS256": verifyRSA(crypto.SHA256, true),
+	"PS384": verifyRSA(crypto.SHA384, true),
+	"PS512": verifyRSA(crypto.SHA512, true),
+	"ES256": verifyECDSA(crypto.SHA256, elliptic.P256()),
+	"ES384": verifyECDSA(crypto.SHA384, elliptic.P384()),
+	"ES512": verifyECDSA(crypto.SHA512, elliptic.P521()),
+	"EdDSA": verifyEd25519,
+}
+
+func verifyRSA(hash crypto.Hash, pss bool) verifier {
+	return func(pub crypto.PublicKey, input, signature []byte) error {
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return errKeyType
+		}
+
+		h := hash.New()
+		h.Write(input)
+		if pss {
+			return rsa.VerifyPSS(key, hash, h.Sum(nil), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), signature)
+	}
+}
+
+// verifyECDSA checks a JWS ECDSA signature, which is r and s concatenated
+// rather than ASN.1 encoded (RFC 7518 section 3.4).
+func verifyECDSA(hash crypto.Hash, curve elliptic.Curve) verifier {
+	return func(pub crypto.PublicKey, input, signature []byte) error {
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok || key.Curve != curve {
+			return errKeyType
+		}
+
+		size := (curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		h := hash.New()
+		h.Write(input)
+		if !ecdsa.Verify(key, h.Sum(nil), r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+}
+
+func verifyEd25519(pub crypto.PublicKey, input, signature []byte) error {
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return errKeyType
+	}
+	if !ed25519.Verify(key, input, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
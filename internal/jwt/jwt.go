@@ -0,0 +1,142 @@
+// Package jwt decodes JSON Web Tokens (RFC 7519) and verifies their
+// signatures against a JSON Web Key Set (RFC 7517), so tokens can be
+// inspected without pasting them into a website.
+//
+// Only asymmetric algorithms are supported: RS*, PS*, ES* and EdDSA. Tokens
+// signed with a shared secret cannot be verified with a public key set.
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed        = errors.New("malformed JWT")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrNoKey            = errors.New("no key of the key set matches the token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+)
+
+// Token is a decoded JWT. Its signature is not checked by Decode, see
+// Verify.
+type Token struct {
+	Header map[string]any
+	Claims map[string]any
+
+	signingInput string
+	signature    []byte
+}
+
+// Decode splits a compact JWS into its header, claims and signature. A
+// "Bearer " prefix and surrounding whitespace are ignored, so a copied
+// Authorization header can be passed as is.
+func Decode(s string) (*Token, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 7 && strings.EqualFold(s[:7], "bearer ") {
+		s = strings.TrimSpace(s[7:])
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrMalformed, len(parts))
+	}
+
+	t := &Token{signingInput: parts[0] + "." + parts[1]}
+	if err := decodePart(parts[0], &t.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrMalformed, err)
+	}
+	if err := decodePart(parts[1], &t.Claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", ErrMalformed, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %w", ErrMalformed, err)
+	}
+	t.signature = signature
+
+	return t, nil
+}
+
+func decodePart(part string, v *map[string]any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// Alg returns the signing algorithm of the header.
+func (t *Token) Alg() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// KeyID returns the kid of the header, empty when there is none.
+func (t *Token) KeyID() string {
+	kid, _ := t.Header["kid"].(string)
+	return kid
+}
+
+// Time returns a NumericDate claim such as exp, iat or nbf.
+func (t *Token) Time(claim string) (time.Time, bool) {
+	n, ok := t.Claims[claim].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// ExpiresAt returns the exp claim.
+func (t *Token) ExpiresAt() (time.Time, bool) {
+	return t.Time("exp")
+}
+
+// Expiry returns the exp claim of token when it is a JWT, and false when it
+// is not or has no exp claim. Opaque access tokens are common, so a token
+// that fails to decode is not an error.
+func Expiry(token string) (time.Time, bool) {
+	t, err := Decode(token)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.ExpiresAt()
+}
+
+const (
+	StatusValid        = "valid"
+	StatusExpired      = "expired"
+	StatusExpiringSoon = "expiring_soon"
+	StatusNotYetValid  = "not_yet_valid"
+)
+
+// Status tells whether the token is usable at now according to its exp and
+// nbf claims. A token that expires within soon is flagged as expiring soon.
+func (t *Token) Status(now time.Time, soon time.Duration) string {
+	if nbf, ok := t.Time("nbf"); ok && now.Before(nbf) {
+		return StatusNotYetValid
+	}
+	if exp, ok := t.ExpiresAt(); ok {
+		if !now.Before(exp) {
+			return StatusExpired
+		}
+		if exp.Sub(now) <= soon {
+			return StatusExpiringSoon
+		}
+	}
+	return StatusValid
+}
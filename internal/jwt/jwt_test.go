@@ -0,0 +1,235 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"jpellissari/dwing/internal/jwt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sign builds a JWT with the header and claims, signed by sign over the
+// signing input.
+func sign(t *testing.T, header, claims map[string]any, sign func(input []byte) []byte) string {
+	t.Helper()
+
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	input := b64(h) + "." + b64(c)
+	return input + "." + b64(sign([]byte(input)))
+}
+
+func digest(hash crypto.Hash, input []byte) []byte {
+	h := hash.New()
+	h.Write(input)
+	return h.Sum(nil)
+}
+
+// keys are generated once, RSA key generation is slow.
+var (
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ = ed25519.GenerateKey(rand.Reader)
+	testJWKS    = jwt.JWKS{Keys: []jwt.JWK{rsaJWK("rsa-1"), ecJWK("ec-1"), edJWK("ed-1")}}
+	testClaims  = map[string]any{"sub": "alice", "exp": 2000000000, "iat": 1700000000}
+	signWithRSA = func(input []byte) []byte {
+		s, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest(crypto.SHA256, input))
+		return s
+	}
+	signWithPSS = func(input []byte) []byte {
+		s, _ := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest(crypto.SHA256, input), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		return s
+	}
+	signWithEd    = func(input []byte) []byte { return ed25519.Sign(edKey, input) }
+	signWithECDSA = func(input []byte) []byte {
+		r, s, _ := ecdsa.Sign(rand.Reader, ecKey, digest(crypto.SHA256, input))
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+)
+
+func rsaJWK(kid string) jwt.JWK {
+	return jwt.JWK{Kty: "RSA", Kid: kid, N: b64(rsaKey.N.Bytes()), E: b64(big.NewInt(int64(rsaKey.E)).Bytes())}
+}
+
+func ecJWK(kid string) jwt.JWK {
+	point, _ := ecKey.PublicKey.Bytes()
+	return jwt.JWK{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(point[1:33]), Y: b64(point[33:])}
+}
+
+func edJWK(kid string) jwt.JWK {
+	return jwt.JWK{Kty: "OKP", Kid: kid, Crv: "Ed25519", X: b64(edKey.Public().(ed25519.PublicKey))}
+}
+
+func TestDecode(t *testing.T) {
+	raw := sign(t, map[string]any{"alg": "RS256", "kid": "rsa-1", "typ": "JWT"}, map[string]any{"sub": "alice", "exp": 1700000000.5, "aud": []string{"api"}}, signWithRSA)
+
+	token, err := jwt.Decode("Bearer " + raw + "\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, "RS256", token.Alg())
+	assert.Equal(t, "rsa-1", token.KeyID())
+	assert.Equal(t, "alice", token.Claims["sub"])
+
+	exp, ok := token.ExpiresAt()
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(1700000000, 500000000), exp)
+
+	_, ok = token.Time("iat")
+	assert.False(t, ok)
+
+	for _, malformed := range []string{"opaque-token", "a.b", "e30.!!!.sig", "e30.e30.!!!", "bm90IGpzb24.e30.sig"} {
+		_, err := jwt.Decode(malformed)
+		assert.ErrorIs(t, err, jwt.ErrMalformed, malformed)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	exp, ok := jwt.Expiry(sign(t, map[string]any{"alg": "EdDSA"}, testClaims, signWithEd))
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(2000000000, 0), exp)
+
+	_, ok = jwt.Expiry("opaque-token")
+	assert.False(t, ok)
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token := func(claims map[string]any) *jwt.Token {
+		parsed, err := jwt.Decode(sign(t, map[string]any{"alg": "EdDSA"}, claims, signWithEd))
+		require.NoError(t, err)
+		return parsed
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   string
+	}{
+		{name: "No expiry", claims: map[string]any{"sub": "alice"}, want: jwt.StatusValid},
+		{name: "Valid", claims: map[string]any{"exp": now.Unix() + 3600}, want: jwt.StatusValid},
+		{name: "Expiring soon", claims: map[string]any{"exp": now.Unix() + 60}, want: jwt.StatusExpiringSoon},
+		{name: "Expired", claims: map[string]any{"exp": now.Unix()}, want: jwt.StatusExpired},
+		{name: "Not yet valid", claims: map[string]any{"nbf": now.Unix() + 60, "exp": now.Unix() + 3600}, want: jwt.StatusNotYetValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, token(tt.claims).Status(now, 5*time.Minute))
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  map[string]any
+		sign    func([]byte) []byte
+		keys    jwt.JWKS
+		wantKid string
+		wantErr error
+	}{
+		{name: "RS256 by kid", header: map[string]any{"alg": "RS256", "kid": "rsa-1"}, sign: signWithRSA, keys: testJWKS, wantKid: "rsa-1"},
+		{name: "PS256", header: map[string]any{"alg": "PS256", "kid": "rsa-1"}, sign: signWithPSS, keys: testJWKS, wantKid: "rsa-1"},
+		{name: "ES256 without kid", header: map[string]any{"alg": "ES256"}, sign: signWithECDSA, keys: testJWKS, wantKid: "ec-1"},
+		{name: "EdDSA", header: map[string]any{"alg": "EdDSA", "kid": "ed-1"}, sign: signWithEd, keys: testJWKS, wantKid: "ed-1"},
+		{name: "Unknown kid", header: map[string]any{"alg": "RS256", "kid": "rsa-2"}, sign: signWithRSA, keys: testJWKS, wantErr: jwt.ErrNoKey},
+		{
+			name:    "Signed by another key",
+			header:  map[string]any{"alg": "RS256", "kid": "rsa-1"},
+			sign:    signWithRSA,
+			keys:    jwt.JWKS{Keys: []jwt.JWK{{Kty: "RSA", Kid: "rsa-1", N: b64(big.NewInt(0).Lsh(big.NewInt(1), 2047).Bytes()), E: "AQAB"}}},
+			wantErr: jwt.ErrInvalidSignature,
+		},
+		{
+			name:    "Algorithm of the header does not fit the key",
+			header:  map[string]any{"alg": "ES256", "kid": "rsa-1"},
+			sign:    signWithECDSA,
+			keys:    testJWKS,
+			wantErr: jwt.ErrNoKey,
+		},
+		{name: "Shared secret", header: map[string]any{"alg": "HS256"}, sign: func([]byte) []byte { return []byte("mac") }, keys: testJWKS, wantErr: jwt.ErrUnsupportedAlg},
+		{name: "Unsigned", header: map[string]any{"alg": "none"}, sign: func([]byte) []byte { return nil }, keys: testJWKS, wantErr: jwt.ErrUnsupportedAlg},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.Decode(sign(t, tt.header, testClaims, tt.sign))
+			require.NoError(t, err)
+
+			kid, err := token.Verify(tt.keys)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKid, kid)
+		})
+	}
+
+	t.Run("Tampered claims", func(t *testing.T) {
+		raw := sign(t, map[string]any{"alg": "RS256", "kid": "rsa-1"}, testClaims, signWithRSA)
+		parts := strings.Split(raw, ".")
+		parts[1] = b64([]byte(`{"sub":"mallory","exp":2000000000}`))
+
+		token, err := jwt.Decode(strings.Join(parts, "."))
+		require.NoError(t, err)
+
+		_, err = token.Verify(testJWKS)
+		assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+	})
+}
+
+func TestLoadJWKS(t *testing.T) {
+	data, err := json.Marshal(testJWKS)
+	require.NoError(t, err)
+
+	t.Run("URL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(data)
+		}))
+		defer server.Close()
+
+		keys, err := jwt.LoadJWKS(context.Background(), server.Client(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, testJWKS, keys)
+	})
+
+	t.Run("File with a single key", func(t *testing.T) {
+		single, err := json.Marshal(edJWK("ed-1"))
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "key.json")
+		require.NoError(t, os.WriteFile(path, single, 0600))
+
+		keys, err := jwt.LoadJWKS(context.Background(), http.DefaultClient, path)
+		require.NoError(t, err)
+		assert.Equal(t, jwt.JWKS{Keys: []jwt.JWK{edJWK("ed-1")}}, keys)
+	})
+
+	t.Run("Not a key set", func(t *testing.T) {
+		_, err := jwt.ParseJWKS([]byte(`{"issuer": "https://idp"}`))
+		assert.EqualError(t, err, "JWKS has no keys")
+	})
+}
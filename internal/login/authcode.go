@@ -0,0 +1,230 @@
+package login
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"jpellissari/dwing/internal/auth"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAuthorizeTimeout is how long the authorization code flow waits for
+// the browser to come back to the callback listener.
+const DefaultAuthorizeTimeout = 5 * time.Minute
+
+const callbackPath = "/callback"
+
+// AuthCodeFlow implements the OAuth2 authorization code grant (RFC 6749
+// section 4.1) with PKCE (RFC 7636) for native apps (RFC 8252). The user
+// logs in with the identity provider in a browser, which is redirected to an
+// ephemeral listener on 127.0.0.1 with the authorization code.
+//
+// The credential is only used for its username, sent as a login hint.
+type AuthCodeFlow struct {
+	AuthorizeURL string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// OpenURL shows the authorization URL to the user, and usually opens it
+	// in a browser.
+	OpenURL func(authorizeURL string) error
+	// Timeout defaults to DefaultAuthorizeTimeout.
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// callback is what the browser brings back to the listener.
+type callback struct {
+	code string
+	err  error
+}
+
+func (f *AuthCodeFlow) Login(ctx context.Context, cred auth.Credential) (Token, error) {
+	if f.AuthorizeURL == "" {
+		return Token{}, ErrMissingAuthorizeURL
+	}
+	if f.TokenURL == "" {
+		return Token{}, ErrMissingTokenURL
+	}
+	if f.OpenURL == nil {
+		return Token{}, errors.New("authorization code flow needs a way to open the authorization URL")
+	}
+
+	verifier, state, nonce := randomString(), randomString(), randomString()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to start callback listener: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr(), callbackPath)
+
+	authorizeURL, err := f.authorizeURL(cred, redirectURI, verifier, state, nonce)
+	if err != nil {
+		listener.Close()
+		return Token{}, err
+	}
+
+	// The first callback wins, later ones are answered but ignored.
+	callbacks := make(chan callback, 1)
+	server := &http.Server{
+		Handler:           callbackHandler(state, callbacks),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	if err := f.OpenURL(authorizeURL); err != nil {
+		return Token{}, err
+	}
+
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = DefaultAuthorizeTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var cb callback
+	select {
+	case cb = <-callbacks:
+	case <-timer.C:
+		return Token{}, fmt.Errorf("%w after %s", ErrAuthorizeTimeout, timeout)
+	case <-ctx.Done():
+		return Token{}, ctx.Err()
+	}
+	if cb.err != nil {
+		return Token{}, cb.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", cb.code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", verifier)
+
+	token, err := requestToken(ctx, f.HTTPClient, f.TokenURL, f.ClientID, f.ClientSecret, form)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if token.IDToken != "" {
+		if err := checkNonce(token.IDToken, nonce); err != nil {
+			return Token{}, err
+		}
+	}
+
+	return token, nil
+}
+
+func (f *AuthCodeFlow) Refresh(ctx context.Context, cred auth.Credential, refreshToken string) (Token, error) {
+	return refresh(ctx, f.HTTPClient, f.TokenURL, f.ClientID, f.ClientSecret, refreshToken, nil)
+}
+
+func (f *AuthCodeFlow) authorizeURL(cred auth.Credential, redirectURI, verifier, state, nonce string) (string, error) {
+	u, err := url.Parse(f.AuthorizeURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorize URL: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", f.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	if len(f.Scopes) > 0 {
+		q.Set("scope", strings.Join(f.Scopes, " "))
+	}
+	if cred.Username != "" {
+		q.Set("login_hint", cred.Username)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// callbackHandler sends the outcome of the redirect to the callback path on
+// callbacks. A redirect with the wrong state fails the login, as it did not
+// come from the authorization request that was started.
+func callbackHandler(state string, callbacks chan<- callback) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		var cb callback
+		switch {
+		case subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state)) != 1:
+			cb.err = ErrStateMismatch
+		case q.Get("error") != "":
+			cb.err = &OAuthError{Code: q.Get("error"), Description: q.Get("error_description")}
+		case q.Get("code") == "":
+			cb.err = errors.New("authorization server returned no code")
+		default:
+			cb.code = q.Get("code")
+		}
+
+		select {
+		case callbacks <- cb:
+		default:
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if cb.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<p>Login failed: %s</p>", html.EscapeString(cb.err.Error()))
+			return
+		}
+		fmt.Fprint(w, "<p>Login complete, you can close this window and return to the terminal.</p>")
+	})
+	return mux
+}
+
+// checkNonce makes sure an OpenID Connect ID token was issued for this
+// authorization request. Its signature is not verified: it came straight
+// from the token endpoint over the connection that was just opened to it.
+func checkNonce(idToken, nonce string) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed ID token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("malformed ID token: %w", err)
+	}
+
+	var claims struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("malformed ID token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return ErrNonceMismatch
+	}
+	return nil
+}
+
+// randomString returns 32 random bytes encoded for URLs, enough for a PKCE
+// verifier (RFC 7636 section 4.1), a state or a nonce.
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
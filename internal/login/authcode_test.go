@@ -0,0 +1,203 @@
+package login_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/login"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthServer stands in for an identity provider. Its authorize endpoint
+// is never visited: the test browser reads the authorization request and
+// redirects to the callback itself. Codes are bound to the PKCE challenge,
+// redirect URI and nonce of their request.
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+	// idNonce overrides the nonce put in ID tokens when set.
+	idNonce string
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	t.Helper()
+
+	s := &fakeAuthServer{codes: map[string]url.Values{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+
+		s.mu.Lock()
+		req, ok := s.codes[r.PostForm.Get("code")]
+		delete(s.codes, r.PostForm.Get("code"))
+		s.mu.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+			r.PostForm.Get("redirect_uri") != req.Get("redirect_uri") ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != req.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		nonce := req.Get("nonce")
+		if s.idNonce != "" {
+			nonce = s.idNonce
+		}
+		claims, _ := json.Marshal(map[string]string{"sub": req.Get("login_hint"), "nonce": nonce})
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access-" + req.Get("login_hint"),
+			"token_type":    "Bearer",
+			"refresh_token": "refresh",
+			"id_token":      "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig",
+			"expires_in":    3600,
+		})
+	}))
+	return s
+}
+
+// issue returns a code for the authorization request.
+func (s *fakeAuthServer) issue(req url.Values) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := "code-" + req.Get("state")[:8]
+	s.codes[code] = req
+	return code
+}
+
+// browse returns an OpenURL that plays the browser: it checks the
+// authorization request and follows the redirect made by respond.
+func browse(t *testing.T, respond func(req url.Values) url.Values) func(string) error {
+	return func(authorizeURL string) error {
+		u, err := url.Parse(authorizeURL)
+		require.NoError(t, err)
+		req := u.Query()
+
+		assert.Equal(t, "code", req.Get("response_type"))
+		assert.Equal(t, "dwing", req.Get("client_id"))
+		assert.Equal(t, "S256", req.Get("code_challenge_method"))
+		assert.Equal(t, "openid profile", req.Get("scope"))
+
+		redirect, err := url.Parse(req.Get("redirect_uri"))
+		require.NoError(t, err)
+		assert.Equal(t, "127.0.0.1", redirect.Hostname())
+
+		go func() {
+			redirect.RawQuery = respond(req).Encode()
+			resp, err := http.Get(redirect.String())
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+}
+
+func TestAuthCodeFlowLogin(t *testing.T) {
+	server := newFakeAuthServer(t)
+	defer server.Close()
+
+	cred := auth.Credential{Username: "alice"}
+	newFlow := func(open func(string) error) *login.AuthCodeFlow {
+		return &login.AuthCodeFlow{
+			AuthorizeURL: server.URL + "/authorize?audience=api",
+			TokenURL:     server.URL,
+			ClientID:     "dwing",
+			Scopes:       []string{"openid", "profile"},
+			OpenURL:      open,
+			Timeout:      5 * time.Second,
+		}
+	}
+
+	t.Run("Code is exchanged for a token", func(t *testing.T) {
+		flow := newFlow(browse(t, func(req url.Values) url.Values {
+			assert.Equal(t, "api", req.Get("audience"))
+			assert.Equal(t, "alice", req.Get("login_hint"))
+			return url.Values{"code": {server.issue(req)}, "state": {req.Get("state")}}
+		}))
+
+		token, err := flow.Login(context.Background(), cred)
+		require.NoError(t, err)
+		assert.Equal(t, "access-alice", token.AccessToken)
+		assert.Equal(t, "refresh", token.RefreshToken)
+		assert.NotEmpty(t, token.IDToken)
+	})
+
+	t.Run("Wrong state", func(t *testing.T) {
+		flow := newFlow(browse(t, func(req url.Values) url.Values {
+			return url.Values{"code": {server.issue(req)}, "state": {"forged"}}
+		}))
+
+		_, err := flow.Login(context.Background(), cred)
+		assert.ErrorIs(t, err, login.ErrStateMismatch)
+	})
+
+	t.Run("Authorization denied", func(t *testing.T) {
+		flow := newFlow(browse(t, func(req url.Values) url.Values {
+			return url.Values{"error": {"access_denied"}, "error_description": {"user cancelled"}, "state": {req.Get("state")}}
+		}))
+
+		_, err := flow.Login(context.Background(), cred)
+		assert.EqualError(t, err, "authorization server returned access_denied: user cancelled")
+	})
+
+	t.Run("Wrong nonce", func(t *testing.T) {
+		server.idNonce = "replayed"
+		defer func() { server.idNonce = "" }()
+
+		flow := newFlow(browse(t, func(req url.Values) url.Values {
+			return url.Values{"code": {server.issue(req)}, "state": {req.Get("state")}}
+		}))
+
+		_, err := flow.Login(context.Background(), cred)
+		assert.ErrorIs(t, err, login.ErrNonceMismatch)
+	})
+
+	t.Run("Code from another request", func(t *testing.T) {
+		flow := newFlow(browse(t, func(req url.Values) url.Values {
+			other := url.Values{"redirect_uri": {req.Get("redirect_uri")}, "state": {req.Get("state")}, "code_challenge": {"other"}}
+			return url.Values{"code": {server.issue(other)}, "state": {req.Get("state")}}
+		}))
+
+		_, err := flow.Login(context.Background(), cred)
+		assert.ErrorContains(t, err, "invalid_grant")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		flow := newFlow(func(string) error { return nil })
+		flow.Timeout = 50 * time.Millisecond
+
+		_, err := flow.Login(context.Background(), cred)
+		assert.ErrorIs(t, err, login.ErrAuthorizeTimeout)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		flow := newFlow(func(string) error { cancel(); return nil })
+
+		_, err := flow.Login(ctx, cred)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Missing authorize URL", func(t *testing.T) {
+		flow := newFlow(nil)
+		flow.AuthorizeURL = ""
+
+		_, err := flow.Login(context.Background(), cred)
+		assert.ErrorIs(t, err, login.ErrMissingAuthorizeURL)
+	})
+}
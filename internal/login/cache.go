@@ -0,0 +1,53 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/jwt"
+	"time"
+)
+
+// ExpiryLeeway is how long before its expiry a cached token is renewed, so
+// it does not expire on the way to the service it is sent to.
+const ExpiryLeeway = 30 * time.Second
+
+// WithJWTExpiry returns the token with the expiry of the exp claim of its
+// access token, when it is a JWT that expires before the token response
+// said or the response did not say.
+func WithJWTExpiry(t Token) Token {
+	exp, ok := jwt.Expiry(t.AccessToken)
+	if ok && (t.ExpiresAt.IsZero() || exp.Before(t.ExpiresAt)) {
+		t.ExpiresAt = exp
+	}
+	return t
+}
+
+// Obtain returns the token cached on cred while it is valid at now, going by
+// the token response and the exp claim of JWT access tokens. Once it
+// expires the token is refreshed when the flow supports it, and a login is
+// run when it does not or the identity provider rejects the refresh token.
+// The returned bool reports whether the token is new and should be cached.
+func Obtain(ctx context.Context, flow Flow, cred auth.Credential, now time.Time) (Token, bool, error) {
+	cached := cred.Token
+	if cached != nil && cached.AccessToken != "" && !WithJWTExpiry(*cached).Expired(now.Add(ExpiryLeeway)) {
+		return *cached, false, nil
+	}
+
+	if refresher, ok := flow.(Refresher); ok && cached != nil && cached.RefreshToken != "" {
+		token, err := refresher.Refresh(ctx, cred, cached.RefreshToken)
+		if err == nil {
+			return WithJWTExpiry(token), true, nil
+		}
+		var oauthErr *OAuthError
+		if !errors.As(err, &oauthErr) {
+			return Token{}, false, err
+		}
+	}
+
+	token, err := flow.Login(ctx, cred)
+	if err != nil {
+		return Token{}, false, err
+	}
+	return WithJWTExpiry(token), true, nil
+}
@@ -0,0 +1,141 @@
+package login_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/login"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFlow counts logins and refreshes. Refreshes fail with refreshErr when
+// it is set.
+type fakeFlow struct {
+	logins, refreshes int
+	refreshErr        error
+}
+
+func (f *fakeFlow) Login(ctx context.Context, cred auth.Credential) (login.Token, error) {
+	f.logins++
+	return login.Token{AccessToken: "login", RefreshToken: "r1"}, nil
+}
+
+func (f *fakeFlow) Refresh(ctx context.Context, cred auth.Credential, refreshToken string) (login.Token, error) {
+	f.refreshes++
+	if f.refreshErr != nil {
+		return login.Token{}, f.refreshErr
+	}
+	return login.Token{AccessToken: "refreshed:" + refreshToken, RefreshToken: refreshToken}, nil
+}
+
+// loginOnly hides the Refresh method of a flow.
+type loginOnly struct{ login.Flow }
+
+func TestObtain(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	valid := &auth.Token{AccessToken: "cached", RefreshToken: "r1", ExpiresAt: now.Add(time.Hour)}
+	expiring := &auth.Token{AccessToken: "cached", RefreshToken: "r1", ExpiresAt: now.Add(10 * time.Second)}
+
+	tests := []struct {
+		name          string
+		flow          *fakeFlow
+		hideRefresh   bool
+		cached        *auth.Token
+		want          string
+		wantFresh     bool
+		wantLogins    int
+		wantRefreshes int
+	}{
+		{name: "No cached token", flow: &fakeFlow{}, want: "login", wantFresh: true, wantLogins: 1},
+		{name: "Valid cached token", flow: &fakeFlow{}, cached: valid, want: "cached"},
+		{name: "Cached token without expiry", flow: &fakeFlow{}, cached: &auth.Token{AccessToken: "cached"}, want: "cached"},
+		{name: "Expiring token is refreshed", flow: &fakeFlow{}, cached: expiring, want: "refreshed:r1", wantFresh: true, wantRefreshes: 1},
+		{
+			name:        "Flow without refresh logs in",
+			flow:        &fakeFlow{},
+			hideRefresh: true,
+			cached:      expiring,
+			want:        "login", wantFresh: true, wantLogins: 1,
+		},
+		{
+			name:   "Expired token without refresh token logs in",
+			flow:   &fakeFlow{},
+			cached: &auth.Token{AccessToken: "cached", ExpiresAt: now.Add(-time.Minute)},
+			want:   "login", wantFresh: true, wantLogins: 1,
+		},
+		{
+			name:   "Rejected refresh token logs in",
+			flow:   &fakeFlow{refreshErr: &login.OAuthError{StatusCode: 400, Code: "invalid_grant"}},
+			cached: expiring,
+			want:   "login", wantFresh: true, wantLogins: 1, wantRefreshes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flow login.Flow = tt.flow
+			if tt.hideRefresh {
+				flow = loginOnly{tt.flow}
+			}
+
+			token, fresh, err := login.Obtain(context.Background(), flow, auth.Credential{Token: tt.cached}, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, token.AccessToken)
+			assert.Equal(t, tt.wantFresh, fresh)
+			assert.Equal(t, tt.wantLogins, tt.flow.logins)
+			assert.Equal(t, tt.wantRefreshes, tt.flow.refreshes)
+		})
+	}
+
+	t.Run("The exp claim of JWT access tokens is an expiry", func(t *testing.T) {
+		expired := &auth.Token{AccessToken: testJWT(now.Add(-time.Minute)), ExpiresAt: now.Add(time.Hour)}
+		flow := &fakeFlow{}
+
+		_, fresh, err := login.Obtain(context.Background(), loginOnly{flow}, auth.Credential{Token: expired}, now)
+		require.NoError(t, err)
+		assert.True(t, fresh)
+		assert.Equal(t, 1, flow.logins)
+	})
+
+	t.Run("Refresh transport errors are returned", func(t *testing.T) {
+		flow := &fakeFlow{refreshErr: errors.New("connection refused")}
+
+		_, _, err := login.Obtain(context.Background(), flow, auth.Credential{Token: expiring}, now)
+		assert.ErrorContains(t, err, "connection refused")
+		assert.Zero(t, flow.logins)
+	})
+}
+
+// testJWT returns an unsigned JWT that expires at exp.
+func testJWT(exp time.Time) string {
+	claims := fmt.Sprintf(`{"exp":%d}`, exp.Unix())
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + "."
+}
+
+func TestWithJWTExpiry(t *testing.T) {
+	exp := time.Unix(2000000000, 0)
+
+	tests := []struct {
+		name  string
+		token login.Token
+		want  time.Time
+	}{
+		{name: "Opaque token", token: login.Token{AccessToken: "opaque"}},
+		{name: "No expiry in the response", token: login.Token{AccessToken: testJWT(exp)}, want: exp},
+		{name: "Earlier exp", token: login.Token{AccessToken: testJWT(exp), ExpiresAt: exp.Add(time.Hour)}, want: exp},
+		{name: "Later exp", token: login.Token{AccessToken: testJWT(exp), ExpiresAt: exp.Add(-time.Hour)}, want: exp.Add(-time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, login.WithJWTExpiry(tt.token).ExpiresAt)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package login
+
+import (
+	"context"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ClientCredentialsFlow implements the OAuth2 client credentials grant (RFC
+// 6749 section 4.4). A client_credentials credential brings its own client,
+// its username being the client ID and its password the client secret, and
+// its token URL and scopes take precedence over those of the flow. Other
+// credentials log in as the client of the flow, which must then be set.
+type ClientCredentialsFlow struct {
+	TokenURL     string
+	Scopes       []string
+	ClientID     string
+	ClientSecret string
+	HTTPClient   *http.Client
+}
+
+func (f *ClientCredentialsFlow) Login(ctx context.Context, cred auth.Credential) (Token, error) {
+	if cred.Kind() != auth.TypeClientCredentials && f.ClientID == "" {
+		return Token{}, fmt.Errorf("%w: %s", ErrCredentialType, cred.Kind())
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if scopes := f.scopes(cred); len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+
+	clientID, clientSecret := f.client(cred)
+	return requestToken(ctx, f.HTTPClient, f.tokenURL(cred), clientID, clientSecret, form)
+}
+
+// Refresh uses a refresh token, which most identity providers do not issue
+// for this grant but some do.
+func (f *ClientCredentialsFlow) Refresh(ctx context.Context, cred auth.Credential, refreshToken string) (Token, error) {
+	clientID, clientSecret := f.client(cred)
+	return refresh(ctx, f.HTTPClient, f.tokenURL(cred), clientID, clientSecret, refreshToken, nil)
+}
+
+func (f *ClientCredentialsFlow) client(cred auth.Credential) (string, string) {
+	if cred.Kind() == auth.TypeClientCredentials {
+		return cred.Username, cred.Password
+	}
+	return f.ClientID, f.ClientSecret
+}
+
+func (f *ClientCredentialsFlow) tokenURL(cred auth.Credential) string {
+	if u := cred.Get("token_url"); u != "" {
+		return u
+	}
+	return f.TokenURL
+}
+
+func (f *ClientCredentialsFlow) scopes(cred auth.Credential) []string {
+	if s := cred.Get("scopes"); s != "" {
+		return strings.Fields(s)
+	}
+	return f.Scopes
+}
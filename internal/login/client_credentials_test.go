@@ -0,0 +1,158 @@
+package login_test
+
+import (
+	"context"
+	"encoding/json"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/login"
+	"jpellissari/dwing/internal/recipe"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClientServer accepts the client credentials grant for ci-bot and hands
+// out refresh tokens that can be used once.
+func newClientServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	issued := 0
+	refreshTokens := map[string]bool{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+
+		fail := func(code string) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+		}
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "ci-bot" || clientSecret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		switch r.PostForm.Get("grant_type") {
+		case "client_credentials":
+		case "refresh_token":
+			if !refreshTokens[r.PostForm.Get("refresh_token")] {
+				fail("invalid_grant")
+				return
+			}
+			delete(refreshTokens, r.PostForm.Get("refresh_token"))
+		default:
+			fail("unsupported_grant_type")
+			return
+		}
+
+		issued++
+		refreshToken := "refresh-" + strconv.Itoa(issued)
+		refreshTokens[refreshToken] = true
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access-" + strconv.Itoa(issued),
+			"token_type":    "Bearer",
+			"refresh_token": refreshToken,
+			"scope":         r.PostForm.Get("scope"),
+			"expires_in":    300,
+		})
+	}))
+}
+
+func TestClientCredentialsFlowLogin(t *testing.T) {
+	server := newClientServer(t)
+	defer server.Close()
+
+	cred := auth.Credential{Type: auth.TypeClientCredentials, Username: "ci-bot", Password: "s3cret"}
+
+	t.Run("Credential fields take precedence", func(t *testing.T) {
+		c := cred
+		c.Fields = map[string]string{"token_url": server.URL, "scopes": "read write"}
+
+		token, err := (&login.ClientCredentialsFlow{TokenURL: "http://unused", Scopes: []string{"admin"}}).Login(context.Background(), c)
+		require.NoError(t, err)
+		assert.Equal(t, "read write", token.Scope)
+		assert.NotEmpty(t, token.AccessToken)
+	})
+
+	t.Run("Flow settings are the fallback", func(t *testing.T) {
+		token, err := (&login.ClientCredentialsFlow{TokenURL: server.URL, Scopes: []string{"admin"}}).Login(context.Background(), cred)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", token.Scope)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		c := cred
+		c.Password = "wrong"
+
+		_, err := (&login.ClientCredentialsFlow{TokenURL: server.URL}).Login(context.Background(), c)
+		var oauthErr *login.OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "invalid_client", oauthErr.Code)
+	})
+
+	t.Run("Other credential types", func(t *testing.T) {
+		_, err := (&login.ClientCredentialsFlow{TokenURL: server.URL}).Login(context.Background(), auth.Credential{Username: "ci-bot", Password: "s3cret"})
+		assert.ErrorIs(t, err, login.ErrCredentialType)
+	})
+
+	t.Run("Other credential types use the client of the flow", func(t *testing.T) {
+		flow := &login.ClientCredentialsFlow{TokenURL: server.URL, ClientID: "ci-bot", ClientSecret: "s3cret"}
+		other := auth.Credential{Username: "alice", Password: "pass"}
+
+		first, err := flow.Login(context.Background(), other)
+		require.NoError(t, err)
+		assert.NotEmpty(t, first.AccessToken)
+
+		_, err = flow.Refresh(context.Background(), other, first.RefreshToken)
+		require.NoError(t, err)
+
+		c := cred
+		c.Fields = map[string]string{"token_url": server.URL}
+		flow.ClientSecret = "wrong"
+		_, err = flow.Login(context.Background(), c)
+		require.NoError(t, err, "a client_credentials credential brings its own client")
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		flow := &login.ClientCredentialsFlow{TokenURL: server.URL}
+		first, err := flow.Login(context.Background(), cred)
+		require.NoError(t, err)
+
+		second, err := flow.Refresh(context.Background(), cred, first.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.AccessToken, second.AccessToken)
+		assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		_, err = flow.Refresh(context.Background(), cred, first.RefreshToken)
+		assert.ErrorContains(t, err, "invalid_grant")
+	})
+}
+
+func TestNewFlowFor(t *testing.T) {
+	flow, err := login.NewFlowFor(login.Settings{}, auth.Credential{Type: auth.TypeClientCredentials})
+	require.NoError(t, err)
+	assert.IsType(t, &login.ClientCredentialsFlow{}, flow)
+
+	flow, err = login.NewFlowFor(login.Settings{}, auth.Credential{})
+	require.NoError(t, err)
+	assert.IsType(t, &login.PasswordFlow{}, flow)
+
+	flow, err = login.NewFlowFor(login.Settings{Recipe: &recipe.Recipe{}}, auth.Credential{Type: auth.TypeClientCredentials})
+	require.NoError(t, err)
+	assert.IsType(t, &login.RecipeFlow{}, flow)
+
+	_, err = login.NewFlowFor(login.Settings{Flow: login.FlowRecipe}, auth.Credential{})
+	assert.ErrorIs(t, err, login.ErrMissingRecipe)
+
+	flow, err = login.NewFlowFor(login.Settings{Flow: login.FlowPassword}, auth.Credential{Type: auth.TypeClientCredentials})
+	require.NoError(t, err)
+	assert.IsType(t, &login.PasswordFlow{}, flow)
+}
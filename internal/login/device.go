@@ -0,0 +1,177 @@
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is how often the token endpoint is polled when
+	// the identity provider does not say (RFC 8628 section 3.2).
+	DefaultPollInterval = 5 * time.Second
+	// DefaultDeviceCodeLifetime is how long the device code is assumed to be
+	// valid when the identity provider leaves out the required expires_in.
+	// The token endpoint still says when the code really expired.
+	DefaultDeviceCodeLifetime = 10 * time.Minute
+	// slowDownStep is added to the interval on each slow_down response
+	// (RFC 8628 section 3.5).
+	slowDownStep = 5 * time.Second
+
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// DeviceCode is what the user needs to approve a device flow login on
+// another device.
+type DeviceCode struct {
+	UserCode        string
+	VerificationURI string
+	// VerificationURIComplete includes the user code, when the identity
+	// provider supports it.
+	VerificationURIComplete string
+	ExpiresAt               time.Time
+}
+
+// DeviceFlow implements the OAuth2 device authorization grant (RFC 8628),
+// for sessions without a browser. The user opens a URL and enters a code on
+// another device while the token endpoint is polled for the outcome.
+//
+// The credential is not used by the flow, the user logs in on the identity
+// provider's page.
+type DeviceFlow struct {
+	DeviceURL    string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// ShowCode shows the verification URI and user code to the user.
+	ShowCode func(code DeviceCode) error
+	// Sleep waits between polls, it defaults to a timer that stops when ctx
+	// is done.
+	Sleep      func(ctx context.Context, d time.Duration) error
+	HTTPClient *http.Client
+}
+
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+func (f *DeviceFlow) Login(ctx context.Context, cred auth.Credential) (Token, error) {
+	if f.DeviceURL == "" {
+		return Token{}, ErrMissingDeviceURL
+	}
+	if f.TokenURL == "" {
+		return Token{}, ErrMissingTokenURL
+	}
+	if f.ShowCode == nil {
+		return Token{}, errors.New("device flow needs a way to show the user code")
+	}
+
+	form := url.Values{}
+	if len(f.Scopes) > 0 {
+		form.Set("scope", strings.Join(f.Scopes, " "))
+	}
+
+	body, err := postForm(ctx, f.HTTPClient, f.DeviceURL, f.ClientID, f.ClientSecret, form)
+	if err != nil {
+		return Token{}, err
+	}
+
+	var dr deviceAuthorizationResponse
+	if err := json.Unmarshal(body, &dr); err != nil {
+		return Token{}, fmt.Errorf("failed to unmarshal device authorization response: %w", err)
+	}
+	if dr.DeviceCode == "" || dr.UserCode == "" || dr.VerificationURI == "" {
+		return Token{}, errors.New("device authorization response is missing the device code, user code or verification URI")
+	}
+
+	lifetime := DefaultDeviceCodeLifetime
+	if dr.ExpiresIn > 0 {
+		lifetime = time.Duration(dr.ExpiresIn) * time.Second
+	}
+
+	code := DeviceCode{
+		UserCode:                dr.UserCode,
+		VerificationURI:         dr.VerificationURI,
+		VerificationURIComplete: dr.VerificationURIComplete,
+		ExpiresAt:               time.Now().Add(lifetime),
+	}
+	if err := f.ShowCode(code); err != nil {
+		return Token{}, err
+	}
+
+	interval := DefaultPollInterval
+	if dr.Interval > 0 {
+		interval = time.Duration(dr.Interval) * time.Second
+	}
+
+	return f.poll(ctx, dr.DeviceCode, interval, code.ExpiresAt)
+}
+
+// poll asks the token endpoint for the outcome of the login until the user
+// approved or denied it, or the device code expired.
+func (f *DeviceFlow) poll(ctx context.Context, deviceCode string, interval time.Duration, expiresAt time.Time) (Token, error) {
+	sleep := f.Sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	for {
+		if err := sleep(ctx, interval); err != nil {
+			return Token{}, err
+		}
+		if time.Now().After(expiresAt) {
+			return Token{}, ErrDeviceCodeExpired
+		}
+
+		form := url.Values{}
+		form.Set("grant_type", deviceCodeGrant)
+		form.Set("device_code", deviceCode)
+
+		token, err := requestToken(ctx, f.HTTPClient, f.TokenURL, f.ClientID, f.ClientSecret, form)
+		if err == nil {
+			return token, nil
+		}
+
+		var oauthErr *OAuthError
+		if !errors.As(err, &oauthErr) {
+			return Token{}, err
+		}
+		switch oauthErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownStep
+		case "expired_token":
+			return Token{}, ErrDeviceCodeExpired
+		default:
+			return Token{}, err
+		}
+	}
+}
+
+func (f *DeviceFlow) Refresh(ctx context.Context, cred auth.Credential, refreshToken string) (Token, error) {
+	return refresh(ctx, f.HTTPClient, f.TokenURL, f.ClientID, f.ClientSecret, refreshToken, nil)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,203 @@
+package login_test
+
+import (
+	"context"
+	"encoding/json"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/login"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDeviceServer serves the device authorization endpoint on /device and
+// answers token polls with the given errors in turn, then with a token.
+func newDeviceServer(t *testing.T, expiresIn, interval int, polls ...string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /device", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "dwing", r.PostForm.Get("client_id"))
+		assert.Equal(t, "openid", r.PostForm.Get("scope"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"device_code":               "dev-code",
+			"user_code":                 "WDJB-MJHT",
+			"verification_uri":          "https://idp.test/device",
+			"verification_uri_complete": "https://idp.test/device?user_code=WDJB-MJHT",
+			"expires_in":                expiresIn,
+			"interval":                  interval,
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:device_code", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "dev-code", r.PostForm.Get("device_code"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(polls) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": polls[0]})
+			polls = polls[1:]
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "device-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestDeviceFlowLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		interval   int
+		polls      []string
+		wantSleeps []time.Duration
+		wantErr    error
+		errMessage string
+	}{
+		{
+			name:       "Approved after pending polls",
+			interval:   2,
+			polls:      []string{"authorization_pending", "authorization_pending"},
+			wantSleeps: []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		{
+			name:       "Default interval",
+			polls:      []string{"authorization_pending"},
+			wantSleeps: []time.Duration{5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:       "Slow down adds 5 seconds to the interval",
+			interval:   1,
+			polls:      []string{"slow_down", "authorization_pending", "slow_down"},
+			wantSleeps: []time.Duration{time.Second, 6 * time.Second, 6 * time.Second, 11 * time.Second},
+		},
+		{
+			name:       "Denied",
+			interval:   1,
+			polls:      []string{"authorization_pending", "access_denied"},
+			wantSleeps: []time.Duration{time.Second, time.Second},
+			errMessage: "access_denied",
+		},
+		{
+			name:       "Expired",
+			interval:   1,
+			polls:      []string{"expired_token"},
+			wantSleeps: []time.Duration{time.Second},
+			wantErr:    login.ErrDeviceCodeExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newDeviceServer(t, 600, tt.interval, tt.polls...)
+			defer server.Close()
+
+			var shown login.DeviceCode
+			var sleeps []time.Duration
+			flow := &login.DeviceFlow{
+				DeviceURL: server.URL + "/device",
+				TokenURL:  server.URL + "/token",
+				ClientID:  "dwing",
+				Scopes:    []string{"openid"},
+				ShowCode: func(code login.DeviceCode) error {
+					shown = code
+					return nil
+				},
+				Sleep: func(ctx context.Context, d time.Duration) error {
+					sleeps = append(sleeps, d)
+					return nil
+				},
+			}
+
+			token, err := flow.Login(context.Background(), auth.Credential{})
+
+			assert.Equal(t, "WDJB-MJHT", shown.UserCode)
+			assert.Equal(t, "https://idp.test/device", shown.VerificationURI)
+			assert.WithinDuration(t, time.Now().Add(10*time.Minute), shown.ExpiresAt, time.Minute)
+			assert.Equal(t, tt.wantSleeps, sleeps)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.errMessage != "":
+				assert.ErrorContains(t, err, tt.errMessage)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "device-token", token.AccessToken)
+			}
+		})
+	}
+}
+
+func TestDeviceFlowDeadline(t *testing.T) {
+	newFlow := func(url string, sleep func(context.Context, time.Duration) error) *login.DeviceFlow {
+		return &login.DeviceFlow{
+			DeviceURL: url + "/device",
+			TokenURL:  url + "/token",
+			ClientID:  "dwing",
+			Scopes:    []string{"openid"},
+			ShowCode:  func(login.DeviceCode) error { return nil },
+			Sleep:     sleep,
+		}
+	}
+
+	t.Run("Code expires while polling", func(t *testing.T) {
+		server := newDeviceServer(t, 1, 1, "authorization_pending", "authorization_pending")
+		defer server.Close()
+
+		flow := newFlow(server.URL, func(ctx context.Context, d time.Duration) error {
+			time.Sleep(d / 2)
+			return nil
+		})
+
+		_, err := flow.Login(context.Background(), auth.Credential{})
+		assert.ErrorIs(t, err, login.ErrDeviceCodeExpired)
+	})
+
+	t.Run("Missing expires_in uses the default lifetime", func(t *testing.T) {
+		server := newDeviceServer(t, 0, 1, "authorization_pending")
+		defer server.Close()
+
+		var shown login.DeviceCode
+		flow := newFlow(server.URL, func(ctx context.Context, d time.Duration) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		flow.ShowCode = func(code login.DeviceCode) error {
+			shown = code
+			return nil
+		}
+
+		token, err := flow.Login(context.Background(), auth.Credential{})
+		require.NoError(t, err)
+		assert.Equal(t, "device-token", token.AccessToken)
+		assert.WithinDuration(t, time.Now().Add(login.DefaultDeviceCodeLifetime), shown.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Cancelled while waiting", func(t *testing.T) {
+		server := newDeviceServer(t, 600, 1)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		flow := newFlow(server.URL, nil)
+		flow.ShowCode = func(login.DeviceCode) error {
+			cancel()
+			return nil
+		}
+
+		_, err := flow.Login(ctx, auth.Credential{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,49 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingTokenURL  = errors.New("token URL is required")
+	ErrEmptyAccessToken = errors.New("token endpoint returned an empty access token")
+	ErrUnsupportedFlow  = errors.New("unsupported login flow")
+	ErrMissingOTPSeed   = errors.New("login requires an OTP code but the credential has no OTP seed")
+	ErrCredentialType   = errors.New("credential type is not supported by this login flow")
+
+	ErrMissingAuthorizeURL = errors.New("authorize URL is required")
+	ErrAuthorizeTimeout    = errors.New("timed out waiting for the browser login")
+	ErrStateMismatch       = errors.New("authorization response state does not match the request")
+	ErrNonceMismatch       = errors.New("ID token nonce does not match the request")
+
+	ErrMissingDeviceURL  = errors.New("device authorization URL is required")
+	ErrDeviceCodeExpired = errors.New("device code expired before the login was approved")
+
+	ErrMissingRecipe = errors.New("the environment has no login recipe")
+)
+
+// OAuthError is the error body returned by a token endpoint, as described in
+// RFC 6749 section 5.2, or the error an authorization server redirects back
+// with, as described in section 4.1.2.1, which has no status code.
+type OAuthError struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.StatusCode == 0 {
+		if e.Description != "" {
+			return fmt.Sprintf("authorization server returned %s: %s", e.Code, e.Description)
+		}
+		return fmt.Sprintf("authorization server returned %s", e.Code)
+	}
+	if e.Description != "" {
+		return fmt.Sprintf("token endpoint returned %d: %s: %s", e.StatusCode, e.Code, e.Description)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("token endpoint returned %d: %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("token endpoint returned %d", e.StatusCode)
+}
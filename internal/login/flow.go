@@ -0,0 +1,112 @@
+package login
+
+import (
+	"context"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/recipe"
+)
+
+const (
+	FlowPassword          = "password"
+	FlowClientCredentials = "client_credentials"
+	FlowAuthorizationCode = "authorization_code"
+	FlowDeviceCode        = "device_code"
+	FlowRecipe            = "recipe"
+)
+
+var Flows = []string{FlowPassword, FlowClientCredentials, FlowAuthorizationCode, FlowDeviceCode, FlowRecipe}
+
+type Flow interface {
+	Login(ctx context.Context, cred auth.Credential) (Token, error)
+}
+
+// Refresher is implemented by flows whose tokens can be renewed with a
+// refresh token instead of logging in again.
+type Refresher interface {
+	Refresh(ctx context.Context, cred auth.Credential, refreshToken string) (Token, error)
+}
+
+type Settings struct {
+	Flow         string
+	AuthorizeURL string
+	DeviceURL    string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// OTPField is the form field the current TOTP code is sent in, for
+	// identity providers that expect one next to the password.
+	OTPField string
+	// Recipe is the login recipe of the recipe flow, and BaseURL the base
+	// URL of the environment it runs against.
+	Recipe  *recipe.Recipe
+	BaseURL string
+	// OpenURL shows the authorization URL of interactive flows to the user.
+	OpenURL func(url string) error
+	// ShowCode shows the user code of the device flow to the user.
+	ShowCode func(code DeviceCode) error
+}
+
+func NewFlow(s Settings) (Flow, error) {
+	switch s.Flow {
+	case "", FlowPassword:
+		return &PasswordFlow{
+			TokenURL:     s.TokenURL,
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			Scopes:       s.Scopes,
+			OTPField:     s.OTPField,
+		}, nil
+	case FlowClientCredentials:
+		return &ClientCredentialsFlow{
+			TokenURL:     s.TokenURL,
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			Scopes:       s.Scopes,
+		}, nil
+	case FlowAuthorizationCode:
+		return &AuthCodeFlow{
+			AuthorizeURL: s.AuthorizeURL,
+			TokenURL:     s.TokenURL,
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			Scopes:       s.Scopes,
+			OpenURL:      s.OpenURL,
+		}, nil
+	case FlowDeviceCode:
+		return &DeviceFlow{
+			DeviceURL:    s.DeviceURL,
+			TokenURL:     s.TokenURL,
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			Scopes:       s.Scopes,
+			ShowCode:     s.ShowCode,
+		}, nil
+	case FlowRecipe:
+		if s.Recipe == nil {
+			return nil, ErrMissingRecipe
+		}
+		return &RecipeFlow{
+			Recipe:  s.Recipe,
+			BaseURL: s.BaseURL,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFlow, s.Flow)
+	}
+}
+
+// NewFlowFor returns the flow of the settings for cred. Without a flow in the
+// settings, a recipe is run when there is one, client_credentials
+// credentials use the client credentials grant and every other credential
+// the password grant.
+func NewFlowFor(s Settings, cred auth.Credential) (Flow, error) {
+	switch {
+	case s.Flow != "":
+	case s.Recipe != nil:
+		s.Flow = FlowRecipe
+	case cred.Kind() == auth.TypeClientCredentials:
+		s.Flow = FlowClientCredentials
+	}
+	return NewFlow(s)
+}
@@ -0,0 +1,102 @@
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// requestToken posts a form to an OAuth2 token endpoint and decodes the
+// response.
+func requestToken(ctx context.Context, client *http.Client, tokenURL, clientID, clientSecret string, form url.Values) (Token, error) {
+	if tokenURL == "" {
+		return Token{}, ErrMissingTokenURL
+	}
+
+	body, err := postForm(ctx, client, tokenURL, clientID, clientSecret, form)
+	if err != nil {
+		return Token{}, err
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return Token{}, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+
+	if tr.AccessToken == "" {
+		return Token{}, ErrEmptyAccessToken
+	}
+
+	return tr.toToken(time.Now()), nil
+}
+
+// postForm posts a form to an OAuth2 endpoint and returns the body of a
+// successful response, or an OAuthError. Client credentials are sent with
+// HTTP basic auth when a client secret is set, otherwise the client ID
+// travels in the form body.
+func postForm(ctx context.Context, client *http.Client, endpoint, clientID, clientSecret string, form url.Values) ([]byte, error) {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	if clientID != "" && clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		oauthErr := &OAuthError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(body, oauthErr)
+		return nil, oauthErr
+	}
+
+	return body, nil
+}
+
+// refresh exchanges a refresh token for a new token (RFC 6749 section 6).
+// The identity provider may not issue a new refresh token, in which case the
+// one that was used is kept. Scopes are only sent to narrow the token.
+func refresh(ctx context.Context, client *http.Client, tokenURL, clientID, clientSecret, refreshToken string, scopes []string) (Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+
+	token, err := requestToken(ctx, client, tokenURL, clientID, clientSecret, form)
+	if err != nil {
+		return Token{}, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
@@ -0,0 +1,21 @@
+package login
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/otp"
+	"time"
+)
+
+// otpCode returns the current TOTP code of cred.
+func otpCode(cred auth.Credential) (string, error) {
+	if cred.OTPSeed == "" {
+		return "", ErrMissingOTPSeed
+	}
+
+	key, err := otp.Parse(cred.OTPSeed)
+	if err != nil {
+		return "", err
+	}
+
+	return key.Code(time.Now())
+}
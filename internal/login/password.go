@@ -0,0 +1,48 @@
+package login
+
+import (
+	"context"
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PasswordFlow implements the OAuth2 resource owner password credentials
+// grant (RFC 6749 section 4.3).
+type PasswordFlow struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	OTPField     string
+	HTTPClient   *http.Client
+}
+
+func (f *PasswordFlow) Login(ctx context.Context, cred auth.Credential) (Token, error) {
+	if cred.Kind() != auth.TypePassword {
+		return Token{}, fmt.Errorf("%w: %s", ErrCredentialType, cred.Kind())
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", cred.Username)
+	form.Set("password", cred.Password)
+	if len(f.Scopes) > 0 {
+		form.Set("scope", strings.Join(f.Scopes, " "))
+	}
+	if f.OTPField != "" {
+		code, err := otpCode(cred)
+		if err != nil {
+			return Token{}, err
+		}
+		form.Set(f.OTPField, code)
+	}
+
+	return requestToken(ctx, f.HTTPClient, f.TokenURL, f.ClientID, f.ClientSecret, form)
+}
+
+func (f *PasswordFlow) Refresh(ctx context.Context, cred auth.Credential, refreshToken string) (Token, error) {
+	return refresh(ctx, f.HTTPClient, f.TokenURL, f.ClientID, f.ClientSecret, refreshToken, nil)
+}
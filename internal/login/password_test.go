@@ -0,0 +1,163 @@
+package login_test
+
+import (
+	"context"
+	"encoding/json"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/login"
+	"jpellissari/dwing/internal/otp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPasswordServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.PostForm.Get("grant_type") != "password" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "unsupported_grant_type"})
+			return
+		}
+
+		if r.PostForm.Get("username") != "user1" || r.PostForm.Get("password") != "pass1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error":             "invalid_grant",
+				"error_description": "bad credentials",
+			})
+			return
+		}
+
+		if r.PostForm.Has("totp") && !validOTP(r.PostForm.Get("totp")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error":             "invalid_grant",
+				"error_description": "bad otp",
+			})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "token-" + r.PostForm.Get("client_id") + "-" + r.PostForm.Get("scope"),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+}
+
+const testOTPSeed = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// validOTP accepts the code of the current and the previous period, so the
+// test does not fail when the period rolls over mid-request.
+func validOTP(code string) bool {
+	key, _ := otp.Parse(testOTPSeed)
+	now := time.Now()
+	current, _ := key.Code(now)
+	previous, _ := key.Code(now.Add(-key.Period))
+	return code == current || code == previous
+}
+
+func TestPasswordFlowLogin(t *testing.T) {
+	server := newPasswordServer(t)
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		flow        *login.PasswordFlow
+		cred        auth.Credential
+		wantToken   string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:      "valid credential returns token",
+			flow:      &login.PasswordFlow{TokenURL: server.URL, ClientID: "cli", Scopes: []string{"openid", "profile"}},
+			cred:      auth.Credential{Username: "user1", Password: "pass1"},
+			wantToken: "token-cli-openid profile",
+		},
+		{
+			name:        "invalid credential returns oauth error",
+			flow:        &login.PasswordFlow{TokenURL: server.URL},
+			cred:        auth.Credential{Username: "user1", Password: "wrong"},
+			wantErr:     true,
+			errContains: "invalid_grant: bad credentials",
+		},
+		{
+			name:      "otp code is sent in the configured field",
+			flow:      &login.PasswordFlow{TokenURL: server.URL, OTPField: "totp"},
+			cred:      auth.Credential{Username: "user1", Password: "pass1", OTPSeed: testOTPSeed},
+			wantToken: "token--",
+		},
+		{
+			name:        "wrong otp seed is rejected by the server",
+			flow:        &login.PasswordFlow{TokenURL: server.URL, OTPField: "totp"},
+			cred:        auth.Credential{Username: "user1", Password: "pass1", OTPSeed: "JBSWY3DPEHPK3PXP"},
+			wantErr:     true,
+			errContains: "bad otp",
+		},
+		{
+			name:        "otp field without seed returns error",
+			flow:        &login.PasswordFlow{TokenURL: server.URL, OTPField: "totp"},
+			cred:        auth.Credential{Username: "user1", Password: "pass1"},
+			wantErr:     true,
+			errContains: "no OTP seed",
+		},
+		{
+			name:        "other credential types are rejected",
+			flow:        &login.PasswordFlow{TokenURL: server.URL},
+			cred:        auth.Credential{Type: auth.TypeAPIKey, Username: "user1", Password: "pass1"},
+			wantErr:     true,
+			errContains: "not supported by this login flow: api_key",
+		},
+		{
+			name:        "missing token url returns error",
+			flow:        &login.PasswordFlow{},
+			cred:        auth.Credential{Username: "user1", Password: "pass1"},
+			wantErr:     true,
+			errContains: "token URL is required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := tc.flow.Login(context.Background(), tc.cred)
+
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantToken, token.AccessToken)
+			assert.Equal(t, "Bearer", token.TokenType)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestNewFlow(t *testing.T) {
+	flow, err := login.NewFlow(login.Settings{TokenURL: "http://example.com/token"})
+	require.NoError(t, err)
+	assert.IsType(t, &login.PasswordFlow{}, flow)
+
+	flow, err = login.NewFlow(login.Settings{Flow: login.FlowAuthorizationCode})
+	require.NoError(t, err)
+	assert.IsType(t, &login.AuthCodeFlow{}, flow)
+
+	flow, err = login.NewFlow(login.Settings{Flow: login.FlowDeviceCode})
+	require.NoError(t, err)
+	assert.IsType(t, &login.DeviceFlow{}, flow)
+
+	_, err = login.NewFlow(login.Settings{Flow: "magic"})
+	assert.ErrorIs(t, err, login.ErrUnsupportedFlow)
+}
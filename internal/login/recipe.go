@@ -0,0 +1,30 @@
+package login
+
+import (
+	"context"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/recipe"
+	"net/http"
+)
+
+// RecipeFlow logs in with the login recipe of an environment, for APIs with
+// a bespoke authentication endpoint. The recipe sees the credential and the
+// environment's base URL.
+type RecipeFlow struct {
+	Recipe     *recipe.Recipe
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func (f *RecipeFlow) Login(ctx context.Context, cred auth.Credential) (Token, error) {
+	if f.Recipe == nil {
+		return Token{}, ErrMissingRecipe
+	}
+
+	client := f.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	return f.Recipe.Run(ctx, client, recipe.NewData(cred, f.BaseURL))
+}
@@ -0,0 +1,33 @@
+package login
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"time"
+)
+
+// Token is the result of a login. It lives in auth so it can be cached on
+// the credential.
+type Token = auth.Token
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func (r tokenResponse) toToken(now time.Time) Token {
+	t := Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		RefreshToken: r.RefreshToken,
+		IDToken:      r.IDToken,
+		Scope:        r.Scope,
+	}
+	if r.ExpiresIn > 0 {
+		t.ExpiresAt = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return t
+}
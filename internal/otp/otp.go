@@ -0,0 +1,188 @@
+// Package otp generates time-based one-time passwords (RFC 6238).
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmSHA1   = "SHA1"
+	AlgorithmSHA256 = "SHA256"
+	AlgorithmSHA512 = "SHA512"
+
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+)
+
+var (
+	ErrInvalidSeed    = errors.New("invalid OTP seed")
+	ErrUnsupportedURI = errors.New("unsupported otpauth URI")
+)
+
+// Key is a TOTP secret together with the parameters codes are generated
+// with.
+type Key struct {
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    time.Duration
+	Issuer    string
+	Account   string
+}
+
+// Parse reads a seed given either as a base32 secret, as shown next to most
+// QR codes, or as the otpauth://totp/... URI encoded in the QR code itself.
+func Parse(seed string) (Key, error) {
+	seed = strings.TrimSpace(seed)
+	if strings.HasPrefix(strings.ToLower(seed), "otpauth://") {
+		return parseURI(seed)
+	}
+
+	secret, err := decodeSecret(seed)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod}, nil
+}
+
+func parseURI(seed string) (Key, error) {
+	u, err := url.Parse(seed)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+	}
+
+	if !strings.EqualFold(u.Host, "totp") {
+		return Key{}, fmt.Errorf("%w: only totp is supported, got %q", ErrUnsupportedURI, u.Host)
+	}
+
+	q := u.Query()
+
+	secret, err := decodeSecret(q.Get("secret"))
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod}
+
+	if algorithm := q.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if _, err := key.hash(); err != nil {
+			return Key{}, err
+		}
+	}
+
+	if digits := q.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < 6 || key.Digits > 10 {
+			return Key{}, fmt.Errorf("%w: digits must be between 6 and 10", ErrInvalidSeed)
+		}
+	}
+
+	if period := q.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds <= 0 {
+			return Key{}, fmt.Errorf("%w: period must be a positive number of seconds", ErrInvalidSeed)
+		}
+		key.Period = time.Duration(seconds) * time.Second
+	}
+
+	// The label is "issuer:account" or just "account".
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.Account = issuer, strings.TrimSpace(account)
+	} else {
+		key.Account = label
+	}
+	if issuer := q.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	return key, nil
+}
+
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, fmt.Errorf("%w: secret is empty", ErrInvalidSeed)
+	}
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret is not valid base32", ErrInvalidSeed)
+	}
+
+	return secret, nil
+}
+
+func (k Key) hash() (func() hash.Hash, error) {
+	switch k.Algorithm {
+	case "", AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrUnsupportedURI, k.Algorithm)
+	}
+}
+
+// Code returns the code valid at t.
+func (k Key) Code(t time.Time) (string, error) {
+	newHash, err := k.hash()
+	if err != nil {
+		return "", err
+	}
+
+	counter := uint64(t.Unix()) / uint64(k.period().Seconds())
+
+	mac := hmac.New(newHash, k.Secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	digits := k.digits()
+	modulo := uint64(1)
+	for range digits {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, uint64(value)%modulo), nil
+}
+
+// Remaining returns how long the code valid at t stays valid.
+func (k Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.period().Seconds())
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+func (k Key) digits() int {
+	if k.Digits == 0 {
+		return DefaultDigits
+	}
+	return k.Digits
+}
+
+func (k Key) period() time.Duration {
+	if k.Period < time.Second {
+		return DefaultPeriod
+	}
+	return k.Period
+}
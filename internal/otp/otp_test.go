@@ -0,0 +1,110 @@
+package otp_test
+
+import (
+	"encoding/base32"
+	"jpellissari/dwing/internal/otp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from RFC 6238 appendix B, with 8 digits.
+func TestCodeRFC6238(t *testing.T) {
+	secrets := map[string]string{
+		otp.AlgorithmSHA1:   "12345678901234567890",
+		otp.AlgorithmSHA256: "12345678901234567890123456789012",
+		otp.AlgorithmSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{59, otp.AlgorithmSHA1, "94287082"},
+		{59, otp.AlgorithmSHA256, "46119246"},
+		{59, otp.AlgorithmSHA512, "90693936"},
+		{1111111109, otp.AlgorithmSHA1, "07081804"},
+		{1111111109, otp.AlgorithmSHA256, "68084774"},
+		{1111111109, otp.AlgorithmSHA512, "25091201"},
+		{1234567890, otp.AlgorithmSHA1, "89005924"},
+		{2000000000, otp.AlgorithmSHA256, "90698825"},
+		{20000000000, otp.AlgorithmSHA512, "47863826"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			key := otp.Key{
+				Secret:    []byte(secrets[tt.algorithm]),
+				Algorithm: tt.algorithm,
+				Digits:    8,
+				Period:    30 * time.Second,
+			}
+
+			code, err := key.Code(time.Unix(tt.unix, 0))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	encoded := base32.StdEncoding.EncodeToString(secret)
+
+	tests := []struct {
+		name    string
+		seed    string
+		want    otp.Key
+		wantErr error
+	}{
+		{
+			name: "Base32 secret",
+			seed: encoded,
+			want: otp.Key{Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: 30 * time.Second},
+		},
+		{
+			name: "Lowercase grouped secret without padding",
+			seed: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq",
+			want: otp.Key{Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: 30 * time.Second},
+		},
+		{
+			name: "otpauth URI with defaults",
+			seed: "otpauth://totp/ACME:alice@example.com?secret=" + encoded + "&issuer=ACME",
+			want: otp.Key{Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: 30 * time.Second, Issuer: "ACME", Account: "alice@example.com"},
+		},
+		{
+			name: "otpauth URI with parameters",
+			seed: "otpauth://totp/alice?secret=" + encoded + "&algorithm=sha256&digits=8&period=60",
+			want: otp.Key{Secret: secret, Algorithm: otp.AlgorithmSHA256, Digits: 8, Period: 60 * time.Second, Account: "alice"},
+		},
+		{name: "Invalid base32", seed: "not base32!", wantErr: otp.ErrInvalidSeed},
+		{name: "Empty", seed: "", wantErr: otp.ErrInvalidSeed},
+		{name: "HOTP URI", seed: "otpauth://hotp/alice?secret=" + encoded + "&counter=1", wantErr: otp.ErrUnsupportedURI},
+		{name: "Unknown algorithm", seed: "otpauth://totp/alice?secret=" + encoded + "&algorithm=md5", wantErr: otp.ErrUnsupportedURI},
+		{name: "Invalid digits", seed: "otpauth://totp/alice?secret=" + encoded + "&digits=4", wantErr: otp.ErrInvalidSeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := otp.Parse(tt.seed)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	key := otp.Key{Period: 30 * time.Second}
+
+	assert.Equal(t, 30*time.Second, key.Remaining(time.Unix(60, 0)))
+	assert.Equal(t, 1*time.Second, key.Remaining(time.Unix(89, 0)))
+}
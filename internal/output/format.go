@@ -0,0 +1,49 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatTable      = "table"
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatJSONPath   = "jsonpath"
+	FormatGoTemplate = "go-template"
+)
+
+// Format is a parsed --output value. Arg holds the expression of the
+// jsonpath and go-template formats.
+type Format struct {
+	Kind string
+	Arg  string
+}
+
+func ParseFormat(s string) (Format, error) {
+	kind, arg, hasArg := strings.Cut(s, "=")
+
+	switch kind {
+	case "", FormatTable:
+		return Format{Kind: FormatTable}, nil
+	case FormatJSON, FormatYAML:
+		if hasArg {
+			return Format{}, fmt.Errorf("output format %q does not take an argument", kind)
+		}
+		return Format{Kind: kind}, nil
+	case FormatJSONPath, FormatGoTemplate:
+		if arg == "" {
+			return Format{}, fmt.Errorf("output format %q requires an expression, e.g. %s=<expression>", kind, kind)
+		}
+		return Format{Kind: kind, Arg: arg}, nil
+	default:
+		return Format{}, fmt.Errorf("unknown output format %q, expected one of: table, json, yaml, jsonpath=..., go-template=...", s)
+	}
+}
+
+func (f Format) String() string {
+	if f.Arg != "" {
+		return f.Kind + "=" + f.Arg
+	}
+	return f.Kind
+}
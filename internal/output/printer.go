@@ -0,0 +1,125 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/jsonpath"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Printer writes command results in the format picked with --output.
+type Printer struct {
+	Format      Format
+	ShowSecrets bool
+	Out         io.Writer
+}
+
+func NewPrinter(format Format, showSecrets bool, out io.Writer) *Printer {
+	return &Printer{Format: format, ShowSecrets: showSecrets, Out: out}
+}
+
+// Result is the machine readable shape of a command that doesn't return an
+// object of its own, e.g. "credential removed".
+type Result struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Object  any    `json:"object,omitempty"`
+}
+
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Print writes v in the printer's format. The table format is delegated to
+// human, since only the command knows how to lay out its objects.
+func (p *Printer) Print(v any, human func(w io.Writer) error) error {
+	switch p.Format.Kind {
+	case FormatJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(p.Out, string(data))
+		return err
+	case FormatYAML:
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(generic)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		_, err = p.Out.Write(data)
+		return err
+	case FormatJSONPath:
+		tmpl, err := jsonpath.Parse(p.Format.Arg)
+		if err != nil {
+			return err
+		}
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		out, err := tmpl.Execute(generic)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.Out, out)
+		return err
+	case FormatGoTemplate:
+		tmpl, err := template.New("output").Parse(p.Format.Arg)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(p.Out, generic); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		_, err = fmt.Fprintln(p.Out)
+		return err
+	default:
+		return human(p.Out)
+	}
+}
+
+// Success reports a successful command, printing message as is in the table
+// format and a Result otherwise.
+func (p *Printer) Success(message string, object any) error {
+	return p.Print(Result{Status: StatusOK, Message: message, Object: object}, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, message)
+		return err
+	})
+}
+
+// Failure reports a handled failure, such as an unknown credential ID, that
+// should not make the command exit with an error.
+func (p *Printer) Failure(message string) error {
+	return p.Print(Result{Status: StatusError, Message: message}, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "❌ %s\n", message)
+		return err
+	})
+}
+
+// toGeneric round-trips v through JSON, so every format sees the same field
+// names as the json output.
+func toGeneric(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	return generic, nil
+}
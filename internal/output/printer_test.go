@@ -0,0 +1,96 @@
+package output_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/output"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestParseFormat(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    output.Format
+		wantErr bool
+	}{
+		{value: "", want: output.Format{Kind: "table"}},
+		{value: "table", want: output.Format{Kind: "table"}},
+		{value: "json", want: output.Format{Kind: "json"}},
+		{value: "yaml", want: output.Format{Kind: "yaml"}},
+		{value: "jsonpath={.id}", want: output.Format{Kind: "jsonpath", Arg: "{.id}"}},
+		{value: "go-template={{.id}}", want: output.Format{Kind: "go-template", Arg: "{{.id}}"}},
+		{value: "jsonpath=", wantErr: true},
+		{value: "json=x", wantErr: true},
+		{value: "xml", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := output.ParseFormat(tc.value)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPrinterPrint(t *testing.T) {
+	items := []item{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}}
+	human := func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, "human")
+		return err
+	}
+
+	testCases := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "table delegates to human", format: "table", want: "human\n"},
+		{name: "json", format: "json", want: "[\n  {\n    \"id\": \"1\",\n    \"name\": \"one\"\n  },\n  {\n    \"id\": \"2\",\n    \"name\": \"two\"\n  }\n]\n"},
+		{name: "yaml uses json field names", format: "yaml", want: "- id: \"1\"\n  name: one\n- id: \"2\"\n  name: two\n"},
+		{name: "jsonpath", format: "jsonpath={[*].name}", want: "one two\n"},
+		{name: "go-template", format: "go-template={{range .}}{{.id}};{{end}}", want: "1;2;\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := output.ParseFormat(tc.format)
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			printer := output.NewPrinter(format, false, &out)
+
+			require.NoError(t, printer.Print(items, human))
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestPrinterSuccessAndFailure(t *testing.T) {
+	var out bytes.Buffer
+	printer := output.NewPrinter(output.Format{Kind: output.FormatTable}, false, &out)
+
+	require.NoError(t, printer.Success("done", nil))
+	require.NoError(t, printer.Failure("missing"))
+	assert.Equal(t, "done\n❌ missing\n", out.String())
+
+	out.Reset()
+	printer = output.NewPrinter(output.Format{Kind: output.FormatJSON}, false, &out)
+
+	require.NoError(t, printer.Success("done", item{ID: "1"}))
+	assert.JSONEq(t, `{"status":"ok","message":"done","object":{"id":"1","name":""}}`, out.String())
+}
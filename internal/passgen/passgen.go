@@ -0,0 +1,205 @@
+// Package passgen generates random passwords and diceware passphrases from
+// crypto/rand.
+package passgen
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	ClassLower   = "lower"
+	ClassUpper   = "upper"
+	ClassDigits  = "digits"
+	ClassSymbols = "symbols"
+)
+
+// Classes lists the character classes a password can be made of.
+var Classes = []string{ClassLower, ClassUpper, ClassDigits, ClassSymbols}
+
+var classChars = map[string]string{
+	ClassLower:  "abcdefghijklmnopqrstuvwxyz",
+	ClassUpper:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	ClassDigits: "0123456789",
+	// Quotes, backslashes and backticks are left out so passwords can be
+	// pasted in shells and config files without escaping.
+	ClassSymbols: "!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// ambiguousChars are easily confused when a password is read aloud or
+// typed from a screen.
+const ambiguousChars = "Il1|O0o"
+
+const (
+	DefaultLength = 24
+	MinLength     = 12
+	DefaultWords  = 6
+	MinWords      = 4
+	DefaultSep    = "-"
+)
+
+var (
+	ErrTooShort     = errors.New("password is too short")
+	ErrTooFewWords  = errors.New("passphrase has too few words")
+	ErrUnknownClass = errors.New("unknown character class")
+)
+
+// Options tune a generated secret. Zero values pick the defaults: a
+// DefaultLength password using every class, or a DefaultWords passphrase
+// separated by DefaultSep.
+type Options struct {
+	Length           int
+	Classes          []string
+	ExcludeAmbiguous bool
+
+	Passphrase bool
+	Words      int
+	Separator  string
+}
+
+// Generate returns a password or, when opts.Passphrase is set, a passphrase.
+func Generate(opts Options) (string, error) {
+	if opts.Passphrase {
+		return Passphrase(opts)
+	}
+	return Password(opts)
+}
+
+// Password returns a random password with at least one character of every
+// requested class.
+func Password(opts Options) (string, error) {
+	length := opts.Length
+	if length == 0 {
+		length = DefaultLength
+	}
+	if length < MinLength {
+		return "", fmt.Errorf("%w: %d characters, at least %d are required", ErrTooShort, length, MinLength)
+	}
+
+	classes := opts.Classes
+	if len(classes) == 0 {
+		classes = Classes
+	}
+	if err := ValidateClasses(classes); err != nil {
+		return "", err
+	}
+
+	sets := make([]string, 0, len(classes))
+	for _, class := range classes {
+		chars := classChars[class]
+		if opts.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguousChars, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		sets = append(sets, chars)
+	}
+	all := strings.Join(sets, "")
+
+	password := make([]byte, 0, length)
+	for _, set := range sets {
+		c, err := pick(set)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < length {
+		c, err := pick(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// The first characters were drawn one per class, shuffle them away.
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return string(password), nil
+}
+
+// Passphrase returns random words from the EFF large wordlist, which holds
+// 7776 words, so every word adds about 12.9 bits of entropy.
+func Passphrase(opts Options) (string, error) {
+	words := opts.Words
+	if words == 0 {
+		words = DefaultWords
+	}
+	if words < MinWords {
+		return "", fmt.Errorf("%w: %d words, at least %d are required", ErrTooFewWords, words, MinWords)
+	}
+
+	separator := opts.Separator
+	if separator == "" {
+		separator = DefaultSep
+	}
+
+	list := wordlist()
+	picked := make([]string, 0, words)
+	for range words {
+		i, err := randInt(len(list))
+		if err != nil {
+			return "", err
+		}
+		picked = append(picked, list[i])
+	}
+
+	return strings.Join(picked, separator), nil
+}
+
+// ValidateClasses checks that every class is known.
+func ValidateClasses(classes []string) error {
+	for _, class := range classes {
+		if !slices.Contains(Classes, class) {
+			return fmt.Errorf("%w %q, expected one of: %s", ErrUnknownClass, class, strings.Join(Classes, ", "))
+		}
+	}
+	return nil
+}
+
+func pick(chars string) (byte, error) {
+	i, err := randInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+func randInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return int(i.Int64()), nil
+}
+
+// effLargeWordlist is https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt
+// by the Electronic Frontier Foundation, licensed CC BY 3.0 US.
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+var wordlist = sync.OnceValue(func() []string {
+	lines := strings.Split(strings.TrimSpace(effLargeWordlist), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		_, word, _ := strings.Cut(line, "\t")
+		words = append(words, word)
+	}
+	return words
+})
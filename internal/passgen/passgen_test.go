@@ -0,0 +1,92 @@
+package passgen_test
+
+import (
+	"jpellissari/dwing/internal/passgen"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		password, err := passgen.Password(passgen.Options{})
+
+		require.NoError(t, err)
+		assert.Len(t, password, passgen.DefaultLength)
+		assert.True(t, strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz"))
+		assert.True(t, strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+		assert.True(t, strings.ContainsAny(password, "0123456789"))
+	})
+
+	t.Run("every class is present", func(t *testing.T) {
+		for range 100 {
+			password, err := passgen.Password(passgen.Options{Length: passgen.MinLength})
+			require.NoError(t, err)
+			assert.True(t, strings.ContainsAny(password, "!#$%&()*+,-./:;<=>?@[]^_{|}~"), password)
+		}
+	})
+
+	t.Run("only requested classes", func(t *testing.T) {
+		password, err := passgen.Password(passgen.Options{Length: 40, Classes: []string{passgen.ClassDigits}})
+
+		require.NoError(t, err)
+		assert.Len(t, password, 40)
+		assert.Empty(t, strings.Trim(password, "0123456789"))
+	})
+
+	t.Run("ambiguous characters are excluded", func(t *testing.T) {
+		password, err := passgen.Password(passgen.Options{Length: 500, ExcludeAmbiguous: true})
+
+		require.NoError(t, err)
+		assert.False(t, strings.ContainsAny(password, "Il1|O0o"), password)
+	})
+
+	t.Run("passwords differ", func(t *testing.T) {
+		a, err := passgen.Password(passgen.Options{})
+		require.NoError(t, err)
+		b, err := passgen.Password(passgen.Options{})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := passgen.Password(passgen.Options{Length: 6})
+
+		assert.ErrorIs(t, err, passgen.ErrTooShort)
+	})
+
+	t.Run("unknown class", func(t *testing.T) {
+		_, err := passgen.Password(passgen.Options{Classes: []string{"emoji"}})
+
+		assert.ErrorIs(t, err, passgen.ErrUnknownClass)
+	})
+}
+
+func TestPassphrase(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		passphrase, err := passgen.Passphrase(passgen.Options{})
+
+		require.NoError(t, err)
+		words := strings.Split(passphrase, passgen.DefaultSep)
+		assert.Len(t, words, passgen.DefaultWords)
+		for _, w := range words {
+			assert.NotEmpty(t, w)
+		}
+	})
+
+	t.Run("custom words and separator", func(t *testing.T) {
+		passphrase, err := passgen.Generate(passgen.Options{Passphrase: true, Words: 8, Separator: " "})
+
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(passphrase, " "), 8)
+	})
+
+	t.Run("too few words", func(t *testing.T) {
+		_, err := passgen.Passphrase(passgen.Options{Words: 3})
+
+		assert.ErrorIs(t, err, passgen.ErrTooFewWords)
+	})
+}
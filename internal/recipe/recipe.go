@@ -0,0 +1,226 @@
+// Package recipe runs declarative login recipes: ordered HTTP steps that
+// log in to APIs with a bespoke authentication endpoint and produce a token.
+//
+// A recipe is written in YAML:
+//
+//	steps:
+//	  - name: session
+//	    method: POST
+//	    url: "{{.BaseURL}}/api/login"
+//	    headers:
+//	      Content-Type: application/json
+//	    body: '{"user": {{json .Username}}, "password": {{json .Password}}}'
+//	    extract:
+//	      session: {jsonpath: "{.data.session_id}"}
+//	      csrf: {header: X-CSRF-Token}
+//	  - name: token
+//	    method: POST
+//	    url: "{{.BaseURL}}/api/token"
+//	    headers:
+//	      X-CSRF-Token: "{{.Vars.csrf}}"
+//	    form:
+//	      session: "{{.Vars.session}}"
+//	    extract:
+//	      token: {jsonpath: .access_token}
+//	      ttl: {jsonpath: .ttl}
+//	token:
+//	  access_token: "{{.Vars.token}}"
+//	  expires_in: "{{.Vars.ttl}}"
+//
+// URLs, headers, bodies, form values and the token are Go templates over
+// Data. Values extracted by a step are available to the following ones in
+// .Vars. Cookies set by a response are sent back on the following steps.
+package recipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpellissari/dwing/internal/jsonpath"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Recipe struct {
+	Steps []Step    `json:"steps" yaml:"steps"`
+	Token TokenSpec `json:"token" yaml:"token"`
+}
+
+// Step is an HTTP request of a recipe. Method defaults to POST when the step
+// has a body or a form, and to GET otherwise.
+type Step struct {
+	Name    string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+	// Form is sent URL encoded, it cannot be combined with Body.
+	Form map[string]string `json:"form,omitempty" yaml:"form,omitempty"`
+	// ExpectStatus lists the accepted status codes, any 2xx by default.
+	ExpectStatus []int              `json:"expect_status,omitempty" yaml:"expect_status,omitempty"`
+	Extract      map[string]Extract `json:"extract,omitempty" yaml:"extract,omitempty"`
+}
+
+// Extract names where a value is read from in a response. Exactly one of
+// its fields is set.
+type Extract struct {
+	JSONPath string `json:"jsonpath,omitempty" yaml:"jsonpath,omitempty"`
+	Header   string `json:"header,omitempty" yaml:"header,omitempty"`
+	Cookie   string `json:"cookie,omitempty" yaml:"cookie,omitempty"`
+}
+
+// TokenSpec builds the token from the extracted values. ExpiresIn is in
+// seconds, ExpiresAt is an RFC 3339 time or a Unix timestamp. Without
+// either, the token does not expire.
+type TokenSpec struct {
+	AccessToken  string `json:"access_token" yaml:"access_token"`
+	TokenType    string `json:"token_type,omitempty" yaml:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty" yaml:"refresh_token,omitempty"`
+	ExpiresIn    string `json:"expires_in,omitempty" yaml:"expires_in,omitempty"`
+	ExpiresAt    string `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+// Parse reads a recipe from YAML and validates it. Unknown keys are
+// rejected so a misspelled one does not go unnoticed.
+func Parse(data []byte) (*Recipe, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var r Recipe
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to parse recipe: %w", err)
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// Validate checks the structure of the recipe and the syntax of its
+// templates and JSONPath expressions.
+func (r *Recipe) Validate() error {
+	if len(r.Steps) == 0 {
+		return errors.New("recipe has no steps")
+	}
+
+	for i, s := range r.Steps {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("%s: %w", s.label(i), err)
+		}
+	}
+
+	if r.Token.AccessToken == "" {
+		return errors.New("recipe token has no access_token")
+	}
+	if r.Token.ExpiresIn != "" && r.Token.ExpiresAt != "" {
+		return errors.New("recipe token cannot set both expires_in and expires_at")
+	}
+	for _, text := range []string{r.Token.AccessToken, r.Token.TokenType, r.Token.RefreshToken, r.Token.ExpiresIn, r.Token.ExpiresAt} {
+		if _, err := parseTemplate(text); err != nil {
+			return fmt.Errorf("recipe token: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s Step) validate() error {
+	if s.URL == "" {
+		return errors.New("url is required")
+	}
+	if s.Body != "" && len(s.Form) > 0 {
+		return errors.New("body and form cannot be combined")
+	}
+	if s.Method != "" && strings.ContainsAny(s.Method, " \t/") {
+		return fmt.Errorf("invalid method %q", s.Method)
+	}
+	for _, code := range s.ExpectStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid expected status %d", code)
+		}
+	}
+
+	texts := []string{s.URL, s.Body}
+	for _, v := range s.Headers {
+		texts = append(texts, v)
+	}
+	for _, v := range s.Form {
+		texts = append(texts, v)
+	}
+	for _, text := range texts {
+		if _, err := parseTemplate(text); err != nil {
+			return err
+		}
+	}
+
+	for name, e := range s.Extract {
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("extract %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (e Extract) validate() error {
+	set := 0
+	for _, v := range []string{e.JSONPath, e.Header, e.Cookie} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("set exactly one of jsonpath, header or cookie")
+	}
+
+	if e.JSONPath != "" {
+		if _, err := jsonpath.Parse(braced(e.JSONPath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s Step) method() string {
+	if s.Method != "" {
+		return strings.ToUpper(s.Method)
+	}
+	if s.Body != "" || len(s.Form) > 0 {
+		return http.MethodPost
+	}
+	return http.MethodGet
+}
+
+// label names the step in errors, by its name or else its position.
+func (s Step) label(i int) string {
+	if s.Name != "" {
+		return fmt.Sprintf("step %q", s.Name)
+	}
+	return fmt.Sprintf("step %d", i+1)
+}
+
+// braced wraps a bare JSONPath expression such as .data.token in braces.
+func braced(expr string) string {
+	if strings.Contains(expr, "{") {
+		return expr
+	}
+	return "{" + expr + "}"
+}
+
+var funcs = template.FuncMap{
+	// json quotes a value for a JSON body.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
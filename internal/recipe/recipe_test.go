@@ -0,0 +1,107 @@
+package recipe_test
+
+import (
+	"jpellissari/dwing/internal/recipe"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	r, err := recipe.Parse([]byte(heredoc.Doc(`
+		steps:
+		  - name: login
+		    url: "{{.BaseURL}}/login"
+		    body: '{"user": {{json .Username}}}'
+		    expect_status: [200, 201]
+		    extract:
+		      token: {jsonpath: .data.token}
+		      session: {cookie: sid}
+		token:
+		  access_token: "{{.Vars.token}}"
+		  expires_in: "3600"
+	`)))
+	require.NoError(t, err)
+
+	assert.Equal(t, &recipe.Recipe{
+		Steps: []recipe.Step{{
+			Name:         "login",
+			URL:          "{{.BaseURL}}/login",
+			Body:         `{"user": {{json .Username}}}`,
+			ExpectStatus: []int{200, 201},
+			Extract: map[string]recipe.Extract{
+				"token":   {JSONPath: ".data.token"},
+				"session": {Cookie: "sid"},
+			},
+		}},
+		Token: recipe.TokenSpec{AccessToken: "{{.Vars.token}}", ExpiresIn: "3600"},
+	}, r)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{
+			name: "Unknown key",
+			yaml: "steps:\n  - url: http://x\n    headerz: {}\ntoken: {access_token: t}\n",
+			want: "field headerz not found",
+		},
+		{
+			name: "No steps",
+			yaml: "token: {access_token: t}\n",
+			want: "recipe has no steps",
+		},
+		{
+			name: "Missing URL",
+			yaml: "steps:\n  - name: login\ntoken: {access_token: t}\n",
+			want: `step "login": url is required`,
+		},
+		{
+			name: "Body and form",
+			yaml: "steps:\n  - url: http://x\n    body: '{}'\n    form: {a: b}\ntoken: {access_token: t}\n",
+			want: "step 1: body and form cannot be combined",
+		},
+		{
+			name: "Invalid template",
+			yaml: "steps:\n  - url: '{{.BaseURL'\ntoken: {access_token: t}\n",
+			want: "step 1: template:",
+		},
+		{
+			name: "Extract without source",
+			yaml: "steps:\n  - url: http://x\n    extract:\n      token: {}\ntoken: {access_token: t}\n",
+			want: "extract token: set exactly one of jsonpath, header or cookie",
+		},
+		{
+			name: "Extract with two sources",
+			yaml: "steps:\n  - url: http://x\n    extract:\n      token: {header: X, cookie: y}\ntoken: {access_token: t}\n",
+			want: "set exactly one",
+		},
+		{
+			name: "Invalid JSONPath",
+			yaml: "steps:\n  - url: http://x\n    extract:\n      token: {jsonpath: '.items[x'}\ntoken: {access_token: t}\n",
+			want: "extract token: jsonpath",
+		},
+		{
+			name: "No access token",
+			yaml: "steps:\n  - url: http://x\ntoken: {token_type: Bearer}\n",
+			want: "recipe token has no access_token",
+		},
+		{
+			name: "Both expiries",
+			yaml: "steps:\n  - url: http://x\ntoken: {access_token: t, expires_in: '1', expires_at: '2'}\n",
+			want: "cannot set both expires_in and expires_at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := recipe.Parse([]byte(tt.yaml))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package recipe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/jsonpath"
+	"jpellissari/dwing/internal/otp"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Data is what the templates of a recipe are executed with.
+type Data struct {
+	Environment string
+	BaseURL     string
+	Username    string
+	Password    string
+	// Fields are the fields of the credential's type, such as host.
+	Fields map[string]string
+	// Vars holds the values extracted by the steps that already ran.
+	Vars map[string]string
+
+	otpSeed string
+}
+
+// NewData returns the template data for cred in an environment with the
+// given base URL.
+func NewData(cred auth.Credential, baseURL string) Data {
+	return Data{
+		Environment: cred.Environment,
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+		Username:    cred.Username,
+		Password:    cred.Password,
+		Fields:      maps.Clone(cred.Fields),
+		Vars:        map[string]string{},
+		otpSeed:     cred.OTPSeed,
+	}
+}
+
+// OTP returns the current TOTP code of the credential, for {{.OTP}}.
+func (d Data) OTP() (string, error) {
+	if d.otpSeed == "" {
+		return "", errors.New("the credential has no OTP seed")
+	}
+
+	key, err := otp.Parse(d.otpSeed)
+	if err != nil {
+		return "", err
+	}
+	return key.Code(time.Now())
+}
+
+// Run executes the steps of the recipe in order and builds the token. The
+// client is copied with a cookie jar of its own, so cookies only live for
+// the run.
+func (r *Recipe) Run(ctx context.Context, client *http.Client, data Data) (auth.Token, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return auth.Token{}, err
+	}
+	c := *client
+	c.Jar = jar
+
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+
+	for i, s := range r.Steps {
+		if err := s.run(ctx, &c, &data); err != nil {
+			return auth.Token{}, fmt.Errorf("%s: %w", s.label(i), err)
+		}
+	}
+
+	return r.Token.build(data, time.Now())
+}
+
+func (s Step) run(ctx context.Context, client *http.Client, data *Data) error {
+	req, err := s.request(ctx, *data)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if !s.accepts(resp.StatusCode) {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var doc any
+	names := slices.Sorted(maps.Keys(s.Extract))
+	for _, name := range names {
+		e := s.Extract[name]
+		if e.JSONPath != "" && doc == nil {
+			if err := json.Unmarshal(body, &doc); err != nil {
+				return fmt.Errorf("response is not JSON: %w", err)
+			}
+		}
+
+		value, err := e.read(resp, client.Jar, doc)
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", name, err)
+		}
+		data.Vars[name] = value
+	}
+
+	return nil
+}
+
+func (s Step) request(ctx context.Context, data Data) (*http.Request, error) {
+	rawURL, err := render(s.URL, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	contentType := ""
+	switch {
+	case len(s.Form) > 0:
+		form := url.Values{}
+		for k, v := range s.Form {
+			value, err := render(v, data)
+			if err != nil {
+				return nil, err
+			}
+			form.Set(k, value)
+		}
+		body = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case s.Body != "":
+		rendered, err := render(s.Body, data)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(rendered)
+		if json.Valid([]byte(rendered)) {
+			contentType = "application/json"
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, s.method(), rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range s.Headers {
+		value, err := render(v, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(k, value)
+	}
+
+	return req, nil
+}
+
+func (s Step) accepts(status int) bool {
+	if len(s.ExpectStatus) == 0 {
+		return status >= 200 && status <= 299
+	}
+	return slices.Contains(s.ExpectStatus, status)
+}
+
+// read returns the value of the extraction from a response. Cookies are
+// looked up in the jar too, for those set by a redirect along the way.
+func (e Extract) read(resp *http.Response, jar http.CookieJar, doc any) (string, error) {
+	var value string
+	switch {
+	case e.Header != "":
+		value = resp.Header.Get(e.Header)
+	case e.Cookie != "":
+		cookies := resp.Cookies()
+		if jar != nil {
+			cookies = append(cookies, jar.Cookies(resp.Request.URL)...)
+		}
+		for _, c := range cookies {
+			if c.Name == e.Cookie {
+				value = c.Value
+				break
+			}
+		}
+	default:
+		tmpl, err := jsonpath.Parse(braced(e.JSONPath))
+		if err != nil {
+			return "", err
+		}
+		value, err = tmpl.Execute(doc)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if value == "" {
+		return "", errors.New("no value found")
+	}
+	return value, nil
+}
+
+func (t TokenSpec) build(data Data, now time.Time) (auth.Token, error) {
+	var token auth.Token
+	var expiresIn, expiresAt string
+
+	for _, f := range []struct {
+		text string
+		dst  *string
+	}{
+		{t.AccessToken, &token.AccessToken},
+		{t.TokenType, &token.TokenType},
+		{t.RefreshToken, &token.RefreshToken},
+		{t.ExpiresIn, &expiresIn},
+		{t.ExpiresAt, &expiresAt},
+	} {
+		value, err := render(f.text, data)
+		if err != nil {
+			return auth.Token{}, fmt.Errorf("recipe token: %w", err)
+		}
+		*f.dst = value
+	}
+
+	if token.AccessToken == "" {
+		return auth.Token{}, errors.New("recipe token: access_token is empty")
+	}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+
+	switch {
+	case expiresIn != "":
+		seconds, err := strconv.ParseFloat(expiresIn, 64)
+		if err != nil {
+			return auth.Token{}, fmt.Errorf("recipe token: invalid expires_in %q", expiresIn)
+		}
+		token.ExpiresAt = now.Add(time.Duration(seconds * float64(time.Second)))
+	case expiresAt != "":
+		expiry, err := parseTime(expiresAt)
+		if err != nil {
+			return auth.Token{}, fmt.Errorf("recipe token: invalid expires_at %q", expiresAt)
+		}
+		token.ExpiresAt = expiry
+	}
+
+	return token, nil
+}
+
+// parseTime reads an RFC 3339 time or a Unix timestamp in seconds.
+func parseTime(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func render(text string, data Data) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
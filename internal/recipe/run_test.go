@@ -0,0 +1,161 @@
+package recipe_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/recipe"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLegacyServer stands in for an internal API with a two step login: a
+// JSON login that sets a session cookie and a CSRF header, then a form post
+// that trades them for a token.
+func newLegacyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/login", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var body struct{ User, Password string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.User != "alice" || body.Password != `pa"ss` {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "session-1", Path: "/"})
+		w.Header().Set("X-CSRF-Token", "csrf-1")
+		_, _ = io.WriteString(w, `{"data": {"user": {"id": 42}}}`)
+	})
+	mux.HandleFunc("POST /api/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		sid, err := r.Cookie("sid")
+		if err != nil || sid.Value != "session-1" || r.Header.Get("X-CSRF-Token") != "csrf-1" || r.PostForm.Get("user_id") != "42" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"result": {"token": "legacy-token", "ttl": 900}}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+const legacyRecipe = `
+steps:
+  - name: login
+    method: POST
+    url: "{{.BaseURL}}/api/login"
+    body: '{"user": {{json .Username}}, "password": {{json .Password}}}'
+    extract:
+      user_id: {jsonpath: "{.data.user.id}"}
+      csrf: {header: X-CSRF-Token}
+      session: {cookie: sid}
+  - name: token
+    url: "{{.BaseURL}}/api/token"
+    headers:
+      X-CSRF-Token: "{{.Vars.csrf}}"
+    form:
+      user_id: "{{.Vars.user_id}}"
+    expect_status: [201]
+    extract:
+      token: {jsonpath: .result.token}
+      ttl: {jsonpath: .result.ttl}
+token:
+  access_token: "{{.Vars.token}}"
+  refresh_token: "{{.Vars.session}}"
+  expires_in: "{{.Vars.ttl}}"
+`
+
+func TestRun(t *testing.T) {
+	server := newLegacyServer(t)
+	defer server.Close()
+
+	cred := auth.Credential{Environment: "legacy", Username: "alice", Password: `pa"ss`}
+
+	run := func(t *testing.T, yaml string, cred auth.Credential) (auth.Token, error) {
+		t.Helper()
+		r, err := recipe.Parse([]byte(yaml))
+		require.NoError(t, err)
+		return r.Run(context.Background(), server.Client(), recipe.NewData(cred, server.URL+"/"))
+	}
+
+	t.Run("Steps chain their values", func(t *testing.T) {
+		token, err := run(t, legacyRecipe, cred)
+		require.NoError(t, err)
+
+		assert.Equal(t, "legacy-token", token.AccessToken)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Equal(t, "session-1", token.RefreshToken)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), token.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Unexpected status", func(t *testing.T) {
+		c := cred
+		c.Password = "wrong"
+
+		_, err := run(t, legacyRecipe, c)
+		assert.EqualError(t, err, `step "login": unexpected status 401`)
+	})
+
+	t.Run("Missing value", func(t *testing.T) {
+		_, err := run(t, heredoc.Doc(`
+			steps:
+			  - url: "{{.BaseURL}}/api/login"
+			    body: '{"user": "alice", "password": "pa\"ss"}'
+			    extract:
+			      token: {header: Authorization}
+			token:
+			  access_token: "{{.Vars.token}}"
+		`), cred)
+		assert.EqualError(t, err, "step 1: extract token: no value found")
+	})
+
+	t.Run("Unknown variable", func(t *testing.T) {
+		_, err := run(t, heredoc.Doc(`
+			steps:
+			  - url: "{{.BaseURL}}/api/login"
+			    body: '{"user": "alice", "password": "pa\"ss"}'
+			token:
+			  access_token: "{{.Vars.token}}"
+		`), cred)
+		assert.ErrorContains(t, err, `map has no entry for key "token"`)
+	})
+
+	t.Run("Expiry time", func(t *testing.T) {
+		token, err := run(t, heredoc.Doc(`
+			steps:
+			  - url: "{{.BaseURL}}/api/login"
+			    body: '{"user": "alice", "password": "pa\"ss"}'
+			token:
+			  access_token: static
+			  token_type: Session
+			  expires_at: "2030-01-02T03:04:05Z"
+		`), cred)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Session", token.TokenType)
+		assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), token.ExpiresAt.UTC())
+	})
+
+	t.Run("OTP without seed", func(t *testing.T) {
+		_, err := run(t, heredoc.Doc(`
+			steps:
+			  - url: "{{.BaseURL}}/api/login?otp={{.OTP}}"
+			token:
+			  access_token: t
+		`), cred)
+		assert.ErrorContains(t, err, "the credential has no OTP seed")
+	})
+}
@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/otp"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FieldID          = "id"
+	FieldEnvironment = "environment"
+	FieldUsername    = "username"
+	FieldPassword    = "password"
+	FieldNickname    = "nickname"
+	FieldOTP         = "otp"
+)
+
+// Fields lists the credential fields that can be exported. FieldOTP exports
+// the current TOTP code rather than the seed. The fields of the credential
+// type schemas, such as host or private_key, can be exported too.
+var Fields = []string{FieldID, FieldEnvironment, FieldUsername, FieldPassword, FieldNickname, FieldOTP}
+
+var defaultVars = map[string]string{
+	FieldUsername: "DWING_USERNAME",
+	FieldPassword: "DWING_PASSWORD",
+}
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Binding ties a credential reference to the environment variables its
+// fields are exported as.
+type Binding struct {
+	Ref  string
+	Vars map[string]string
+}
+
+// ParseBinding parses a --cred value of the form
+// "<ref>[,<field>=<VAR>...]", e.g. "db,username=PGUSER,password=PGPASSWORD".
+// Without a mapping the username and password are exported as
+// DWING_USERNAME and DWING_PASSWORD.
+func ParseBinding(value string) (Binding, error) {
+	parts := strings.Split(value, ",")
+	b := Binding{Ref: strings.TrimSpace(parts[0]), Vars: map[string]string{}}
+	if b.Ref == "" {
+		return Binding{}, fmt.Errorf("invalid --cred %q: credential reference is required", value)
+	}
+
+	for _, part := range parts[1:] {
+		field, name, ok := strings.Cut(part, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		name = strings.TrimSpace(name)
+		if !ok || field == "" || name == "" {
+			return Binding{}, fmt.Errorf("invalid --cred %q: mapping must look like <field>=<VAR>", value)
+		}
+		if !slices.Contains(Fields, field) && !isTypeField(field) {
+			return Binding{}, fmt.Errorf("invalid --cred %q: unknown credential field %q", value, field)
+		}
+		if !envVarName.MatchString(name) {
+			return Binding{}, fmt.Errorf("invalid --cred %q: %q is not a valid variable name", value, name)
+		}
+		b.Vars[field] = name
+	}
+
+	if len(b.Vars) == 0 {
+		for field, name := range defaultVars {
+			b.Vars[field] = name
+		}
+	}
+
+	return b, nil
+}
+
+// FieldValue returns the value of one of Fields, or of a field of the
+// credential's type, for c.
+func FieldValue(c auth.Credential, field string) (string, error) {
+	switch field {
+	case FieldID:
+		return c.ID, nil
+	case FieldEnvironment:
+		return c.Environment, nil
+	case FieldUsername:
+		return c.Username, nil
+	case FieldPassword:
+		return c.Password, nil
+	case FieldNickname:
+		return c.Nickname, nil
+	case FieldOTP:
+		if c.OTPSeed == "" {
+			return "", fmt.Errorf("credential %s has no OTP seed", c.ID)
+		}
+		key, err := otp.Parse(c.OTPSeed)
+		if err != nil {
+			return "", err
+		}
+		return key.Code(time.Now())
+	default:
+		t, err := auth.LookupType(c.Type)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := t.Field(field); !ok {
+			return "", fmt.Errorf("%s credential %s has no field %q", t.Name, c.ID, field)
+		}
+		return c.Get(field), nil
+	}
+}
+
+// isTypeField reports whether any credential type has the named field.
+func isTypeField(field string) bool {
+	for _, t := range auth.Types {
+		if _, ok := t.Field(field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Environ returns the KEY=value pairs for the resolved credentials, in a
+// stable order. creds must line up with bindings. Two bindings exporting the
+// same variable are rejected rather than silently overwriting each other.
+func Environ(bindings []Binding, creds auth.Credentials) ([]string, error) {
+	if len(bindings) != len(creds) {
+		return nil, fmt.Errorf("expected %d credentials, got %d", len(bindings), len(creds))
+	}
+
+	values := map[string]string{}
+	owners := map[string]string{}
+	for i, b := range bindings {
+		for field, name := range b.Vars {
+			if owner, ok := owners[name]; ok {
+				return nil, fmt.Errorf("variable %s is set by both %q and %q, map it to different names with --cred <ref>,%s=<VAR>", name, owner, b.Ref, field)
+			}
+
+			value, err := FieldValue(creds[i], field)
+			if err != nil {
+				return nil, err
+			}
+
+			owners[name] = b.Ref
+			values[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, name+"="+values[name])
+	}
+
+	return env, nil
+}
@@ -0,0 +1,132 @@
+package runner_test
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/runner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBinding(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		want        runner.Binding
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:  "reference only uses default variables",
+			value: "db",
+			want: runner.Binding{Ref: "db", Vars: map[string]string{
+				"username": "DWING_USERNAME",
+				"password": "DWING_PASSWORD",
+			}},
+		},
+		{
+			name:  "custom mapping",
+			value: "db,username=PGUSER,password=PGPASSWORD",
+			want: runner.Binding{Ref: "db", Vars: map[string]string{
+				"username": "PGUSER",
+				"password": "PGPASSWORD",
+			}},
+		},
+		{
+			name:  "credential type field",
+			value: "db,host=PGHOST",
+			want: runner.Binding{Ref: "db", Vars: map[string]string{
+				"host": "PGHOST",
+			}},
+		},
+		{
+			name:        "empty reference",
+			value:       ",username=PGUSER",
+			wantErr:     true,
+			errContains: "credential reference is required",
+		},
+		{
+			name:        "unknown field",
+			value:       "db,token=TOKEN",
+			wantErr:     true,
+			errContains: "unknown credential field",
+		},
+		{
+			name:        "invalid variable name",
+			value:       "db,username=1USER",
+			wantErr:     true,
+			errContains: "not a valid variable name",
+		},
+		{
+			name:        "malformed mapping",
+			value:       "db,username",
+			wantErr:     true,
+			errContains: "<field>=<VAR>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := runner.ParseBinding(tc.value)
+
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, b)
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	db := auth.Credential{Environment: "dev", Username: "pg", Password: "pgpass"}
+	api := auth.Credential{Environment: "dev", Username: "api", Password: "apipass"}
+
+	t.Run("exports mapped fields in order", func(t *testing.T) {
+		dbBinding, _ := runner.ParseBinding("db,username=PGUSER,password=PGPASSWORD")
+		apiBinding, _ := runner.ParseBinding("api")
+
+		env, err := runner.Environ([]runner.Binding{dbBinding, apiBinding}, auth.Credentials{db, api})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"DWING_PASSWORD=apipass",
+			"DWING_USERNAME=api",
+			"PGPASSWORD=pgpass",
+			"PGUSER=pg",
+		}, env)
+	})
+
+	t.Run("exports credential type fields", func(t *testing.T) {
+		pg := auth.Credential{Environment: "dev", Type: auth.TypeDatabase, Username: "pg", Password: "pgpass", Fields: map[string]string{"host": "db.dev"}}
+		binding, _ := runner.ParseBinding("pg,host=PGHOST,port=PGPORT")
+
+		env, err := runner.Environ([]runner.Binding{binding}, auth.Credentials{pg})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PGHOST=db.dev", "PGPORT="}, env)
+	})
+
+	t.Run("fields of another type are rejected", func(t *testing.T) {
+		binding, _ := runner.ParseBinding("db,host=PGHOST")
+
+		_, err := runner.Environ([]runner.Binding{binding}, auth.Credentials{db})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `has no field "host"`)
+	})
+
+	t.Run("conflicting variables are rejected", func(t *testing.T) {
+		dbBinding, _ := runner.ParseBinding("db")
+		apiBinding, _ := runner.ParseBinding("api")
+
+		_, err := runner.Environ([]runner.Binding{dbBinding, apiBinding}, auth.Credentials{db, api})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is set by both")
+	})
+}
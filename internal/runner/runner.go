@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"syscall"
+)
+
+var (
+	forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+	// terminalSignals are sent by the terminal to the whole foreground
+	// process group, which the child is part of. They are caught so they do
+	// not kill dwing before the child, but not forwarded, or the child would
+	// get them twice.
+	terminalSignals = []os.Signal{os.Interrupt, syscall.SIGQUIT}
+)
+
+// Run executes argv with the current environment plus env, wiring the
+// child to our stdio and forwarding termination signals to it. It returns
+// the child's exit code; a child killed by a signal reports 128+signal like
+// a shell would.
+func Run(argv []string, env []string) (int, error) {
+	if len(argv) == 0 {
+		return 0, errors.New("no command given")
+	}
+
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to find command: %w", err)
+	}
+
+	child := exec.Command(path, argv[1:]...)
+	child.Env = append(os.Environ(), env...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	signal.Notify(signals, terminalSignals...)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if slices.Contains(terminalSignals, sig) {
+					continue
+				}
+				_ = child.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = child.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return 0, fmt.Errorf("failed to wait for command: %w", err)
+		}
+	}
+
+	return exitCode(child.ProcessState), nil
+}
+
+func exitCode(state *os.ProcessState) int {
+	if code := state.ExitCode(); code >= 0 {
+		return code
+	}
+
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return 1
+}
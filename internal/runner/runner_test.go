@@ -0,0 +1,46 @@
+package runner_test
+
+import (
+	"jpellissari/dwing/internal/runner"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("passes through exit code", func(t *testing.T) {
+		code, err := runner.Run([]string{"sh", "-c", "exit 3"}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, code)
+	})
+
+	t.Run("child sees injected variables", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+
+		code, err := runner.Run([]string{"sh", "-c", `printf %s "$PGUSER" > "$OUT"`}, []string{"PGUSER=pg", "OUT=" + out})
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, code)
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, "pg", string(data))
+	})
+
+	t.Run("signalled child reports 128+signal", func(t *testing.T) {
+		code, err := runner.Run([]string{"sh", "-c", "kill -TERM $$"}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, 143, code)
+	})
+
+	t.Run("unknown command returns error", func(t *testing.T) {
+		_, err := runner.Run([]string{"dwing-command-that-does-not-exist"}, nil)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+//go:build unix
+
+package runner_test
+
+import (
+	"jpellissari/dwing/internal/runner"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSignals(t *testing.T) {
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+	interrupted := filepath.Join(dir, "interrupted")
+
+	script := `trap 'echo int >> "$INTERRUPTED"' INT; touch "$READY"; while :; do sleep 0.05; done`
+
+	type result struct {
+		code int
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		code, err := runner.Run([]string{"sh", "-c", script}, []string{"READY=" + ready, "INTERRUPTED=" + interrupted})
+		done <- result{code, err}
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(ready)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The terminal sends SIGINT to the child itself, dwing must not pass
+	// it on a second time.
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+	time.Sleep(200 * time.Millisecond)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	select {
+	case r := <-done:
+		require.NoError(t, r.err)
+		assert.Equal(t, 143, r.code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM was not forwarded to the command")
+	}
+
+	_, err := os.Stat(interrupted)
+	assert.True(t, os.IsNotExist(err), "SIGINT should not be forwarded")
+}
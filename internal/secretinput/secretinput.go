@@ -0,0 +1,85 @@
+// Package secretinput reads secrets from places other than process arguments:
+// standard input, files, file descriptors and the output of other commands.
+package secretinput
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var ErrEmpty = errors.New("secret is empty")
+
+// Read reads a secret from r. A single trailing newline is dropped so that
+// 'echo "$PASSWORD" | dwing ...' works as expected.
+func Read(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+
+	secret := string(data)
+	secret = strings.TrimSuffix(secret, "\n")
+	secret = strings.TrimSuffix(secret, "\r")
+
+	if secret == "" {
+		return "", ErrEmpty
+	}
+
+	return secret, nil
+}
+
+func ReadFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open secret file: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// ReadFD reads a secret from an inherited file descriptor, e.g.
+// 'dwing ... --password-fd 3 3< secret.txt'.
+func ReadFD(fd int) (string, error) {
+	if fd < 0 {
+		return "", fmt.Errorf("invalid file descriptor %d", fd)
+	}
+
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd))
+	if f == nil {
+		return "", fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// ReadCommand runs command through the shell and reads the secret from its
+// standard output. Standard error and input are passed through so password
+// managers can prompt the user.
+func ReadCommand(ctx context.Context, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("secret command failed: %w", err)
+	}
+
+	return Read(&stdout)
+}
@@ -0,0 +1,90 @@
+package secretinput_test
+
+import (
+	"context"
+	"jpellissari/dwing/internal/secretinput"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "Plain", input: "s3cret", want: "s3cret"},
+		{name: "Trailing newline", input: "s3cret\n", want: "s3cret"},
+		{name: "Trailing CRLF", input: "s3cret\r\n", want: "s3cret"},
+		{name: "Only one newline is dropped", input: "s3cret\n\n", want: "s3cret\n"},
+		{name: "Surrounding spaces are kept", input: " s3cret \n", want: " s3cret "},
+		{name: "Empty", input: "", wantErr: secretinput.ErrEmpty},
+		{name: "Only newline", input: "\n", wantErr: secretinput.ErrEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := secretinput.Read(strings.NewReader(tt.input))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0600))
+
+	got, err := secretinput.ReadFile(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", got)
+
+	_, err = secretinput.ReadFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestReadFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	_, err = w.WriteString("from-fd\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	got, err := secretinput.ReadFD(int(r.Fd()))
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-fd", got)
+
+	_, err = secretinput.ReadFD(-1)
+	assert.Error(t, err)
+}
+
+func TestReadCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	got, err := secretinput.ReadCommand(context.Background(), "printf 'from-cmd\\n'")
+	require.NoError(t, err)
+	assert.Equal(t, "from-cmd", got)
+
+	_, err = secretinput.ReadCommand(context.Background(), "exit 3")
+	assert.EqualError(t, err, "secret command failed: exit status 3")
+
+	_, err = secretinput.ReadCommand(context.Background(), "true")
+	assert.ErrorIs(t, err, secretinput.ErrEmpty)
+}
@@ -0,0 +1,81 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// bitwardenLogin is the item type of logins, cards, notes and identities
+// being the others.
+const bitwardenLogin = 1
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []struct {
+		Type     int    `json:"type"`
+		Name     string `json:"name"`
+		FolderID string `json:"folderId"`
+		Login    *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			TOTP     string `json:"totp"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	} `json:"items"`
+}
+
+// parseBitwarden reads the unencrypted JSON export of Bitwarden. A custom
+// field named "environment" sets the environment of an item.
+func parseBitwarden(r io.Reader) ([]Entry, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	if export.Encrypted {
+		return nil, errors.New("encrypted exports are not supported, export as unencrypted JSON")
+	}
+
+	folders := map[string]string{}
+	for _, f := range export.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	var entries []Entry
+	for _, item := range export.Items {
+		if item.Type != bitwardenLogin || item.Login == nil {
+			continue
+		}
+
+		var uri string
+		if len(item.Login.URIs) > 0 {
+			uri = item.Login.URIs[0].URI
+		}
+
+		entry := Entry{Source: sourceName(item.Name, uri), Folder: folders[item.FolderID]}
+		entry.Credential.Nickname = Nickname(entry.Source)
+		entry.Credential.Username = item.Login.Username
+		entry.Credential.Password = item.Login.Password
+		entry.Credential.OTPSeed = item.Login.TOTP
+		for _, f := range item.Fields {
+			if strings.EqualFold(f.Name, "environment") {
+				entry.Credential.Environment = f.Value
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,61 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+var ErrBundleKey = errors.New("bundle cannot be opened with this passphrase or identity")
+
+// Encrypt returns a writer that encrypts a bundle for the recipients with
+// age: an age X25519 public key, or a passphrase with age.ScryptRecipient.
+// The bundle is ASCII armored, so it can be printed and pasted, and is only
+// complete once the writer is closed.
+func Encrypt(w io.Writer, recipients ...age.Recipient) (io.WriteCloser, error) {
+	armored := armor.NewWriter(w)
+	encrypted, err := age.Encrypt(armored, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	return &bundleWriter{WriteCloser: encrypted, armor: armored}, nil
+}
+
+type bundleWriter struct {
+	io.WriteCloser
+	armor io.WriteCloser
+}
+
+func (w *bundleWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return w.armor.Close()
+}
+
+// Decrypt opens a bundle written by Encrypt, or by the age command line tool
+// with or without --armor. The content is read as FormatJSON.
+func Decrypt(r io.Reader, identities ...age.Identity) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	start, _ := buffered.Peek(len(armor.Header) + 16)
+
+	var src io.Reader = buffered
+	if bytes.HasPrefix(bytes.TrimLeft(start, " \t\r\n"), []byte(armor.Header)) {
+		src = armor.NewReader(buffered)
+	}
+
+	decrypted, err := age.Decrypt(src, identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, ErrBundleKey
+		}
+		return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+	return decrypted, nil
+}
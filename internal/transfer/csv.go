@@ -0,0 +1,89 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvColumns maps the columns the CSV formats are read from to the header
+// names used by each password manager.
+var csvColumns = map[string][]string{
+	"title":       {"title", "name"},
+	"url":         {"url", "website"},
+	"username":    {"username"},
+	"password":    {"password"},
+	"otp":         {"otpauth", "one-time password", "otp"},
+	"folder":      {"vault", "folder", "group"},
+	"environment": {"environment"},
+}
+
+// csvRequired lists the headers a file must have to be read as the format,
+// so a file exported by another tool is rejected rather than half imported.
+var csvRequired = map[string][]string{
+	Format1Password: {"title", "username", "password"},
+	FormatChrome:    {"name", "url", "username", "password"},
+	FormatFirefox:   {"url", "username", "password"},
+}
+
+// parseCSV reads the CSV exports of 1Password, Chrome and Firefox. Besides
+// their own columns, an "environment" column sets the environment of a row.
+func parseCSV(format string, r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Excel and 1Password write a byte order mark before the header.
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+
+	for _, name := range csvRequired[format] {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(column string) string {
+			for _, name := range csvColumns[column] {
+				if i, ok := index[name]; ok && i < len(record) {
+					return record[i]
+				}
+			}
+			return ""
+		}
+
+		entry := Entry{Source: sourceName(value("title"), value("url")), Folder: value("folder")}
+		entry.Credential.Environment = value("environment")
+		entry.Credential.Nickname = Nickname(entry.Source)
+		entry.Credential.Username = value("username")
+		entry.Credential.Password = value("password")
+		entry.Credential.OTPSeed = value("otp")
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,173 @@
+package transfer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dotenvSuffixes maps the variable suffixes of a dotenv file to credential
+// fields. A variable whose suffix is not listed sets the type field of the
+// same name, e.g. DB_HOST sets the host of the DB credential.
+var dotenvSuffixes = map[string]string{
+	"USERNAME":    auth.FieldUsername,
+	"USER":        auth.FieldUsername,
+	"PASSWORD":    auth.FieldPassword,
+	"PASS":        auth.FieldPassword,
+	"OTP_SEED":    auth.FieldOTPSeed,
+	"ENVIRONMENT": "environment",
+	"NICKNAME":    "nickname",
+	"TYPE":        "type",
+}
+
+// parseDotenv reads KEY=value lines. Variables are grouped into credentials
+// by the prefix before their suffix: DB_USER and DB_PASSWORD make up the DB
+// credential, nicknamed "db". Only a prefix that has a username or password
+// variable starts a credential, and variables that match no field of the
+// credential's type are ignored.
+func parseDotenv(r io.Reader) ([]Entry, error) {
+	type variable struct{ key, value string }
+	var vars []variable
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		vars = append(vars, variable{key: strings.ToUpper(key), value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var prefixes []string
+	for _, v := range vars {
+		prefix, field := splitDotenvKey(v.key, nil)
+		if (field == auth.FieldUsername || field == auth.FieldPassword) && !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	entries := make([]Entry, len(prefixes))
+	for i, prefix := range prefixes {
+		entries[i].Source = prefix
+		entries[i].Credential.Nickname = Nickname(strings.ReplaceAll(prefix, "_", "-"))
+	}
+
+	for _, v := range vars {
+		prefix, field := splitDotenvKey(v.key, prefixes)
+		i := slices.Index(prefixes, prefix)
+		if i < 0 {
+			continue
+		}
+
+		c := &entries[i].Credential
+		switch field {
+		case "environment":
+			c.Environment = v.value
+		case "nickname":
+			c.Nickname = v.value
+		case "type":
+			if v.value != auth.TypePassword {
+				c.Type = v.value
+			}
+		default:
+			c.Set(field, v.value)
+		}
+	}
+
+	// Variables such as DB_URL are common in dotenv files but have no field
+	// in the schema of the credential, so they are dropped.
+	for i := range entries {
+		t, err := auth.LookupType(entries[i].Credential.Type)
+		if err != nil {
+			continue
+		}
+		for name := range entries[i].Credential.Fields {
+			if _, ok := t.Field(name); !ok {
+				delete(entries[i].Credential.Fields, name)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// splitDotenvKey splits a variable name into its prefix and the field it
+// sets. With no prefixes it only recognizes the suffixes of dotenvSuffixes,
+// otherwise it picks the longest matching prefix.
+func splitDotenvKey(key string, prefixes []string) (string, string) {
+	if prefixes == nil {
+		for suffix, field := range dotenvSuffixes {
+			if key == suffix {
+				return "", field
+			}
+			if prefix, ok := strings.CutSuffix(key, "_"+suffix); ok {
+				return prefix, field
+			}
+		}
+		return "", ""
+	}
+
+	best := -1
+	for i, prefix := range prefixes {
+		if (prefix == "" || strings.HasPrefix(key, prefix+"_")) && (best < 0 || len(prefix) > len(prefixes[best])) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", ""
+	}
+
+	rest := key
+	if prefix := prefixes[best]; prefix != "" {
+		rest = strings.TrimPrefix(key, prefix+"_")
+	}
+	if field, ok := dotenvSuffixes[rest]; ok {
+		return prefixes[best], field
+	}
+	return prefixes[best], strings.ToLower(rest)
+}
+
+// dotenvValue unquotes a value. Double quoted values understand the escapes
+// of Go strings, single quoted ones are taken literally and unquoted ones end
+// at a " #" comment.
+func dotenvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", errors.New("invalid quoted value")
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", errors.New("invalid quoted value")
+		}
+		return value[1 : len(value)-1], nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
@@ -0,0 +1,189 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExportFormats lists the formats Export writes. FormatBundle is FormatJSON
+// written through Encrypt.
+var ExportFormats = []string{FormatBundle, FormatJSON, FormatCSV, FormatDotenv}
+
+// exportColumns are the first columns of a CSV export, followed by one column
+// per type field, such as host.
+var exportColumns = []string{"id", "environment", "type", "username", auth.FieldPassword, "nickname", auth.FieldOTPSeed}
+
+// Export writes creds with their secrets in a plaintext format. Every format
+// is read back by Parse.
+func Export(format string, w io.Writer, creds auth.Credentials) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(creds)
+	case FormatCSV:
+		return exportCSV(w, creds)
+	case FormatDotenv:
+		return exportDotenv(w, creds)
+	default:
+		return fmt.Errorf("%w %q, expected one of: %s", ErrUnknownFormat, format, strings.Join([]string{FormatJSON, FormatCSV, FormatDotenv}, ", "))
+	}
+}
+
+func exportCSV(w io.Writer, creds auth.Credentials) error {
+	var fields []string
+	for _, c := range creds {
+		for name := range c.Fields {
+			if !slices.Contains(fields, name) {
+				fields = append(fields, name)
+			}
+		}
+	}
+	slices.Sort(fields)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(slices.Clone(exportColumns), fields...)); err != nil {
+		return err
+	}
+	for _, c := range creds {
+		record := []string{c.ID, c.Environment, c.Kind(), c.Username, c.Password, c.Nickname, c.OTPSeed}
+		for _, name := range fields {
+			record = append(record, c.Fields[name])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+var dotenvInvalid = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// exportDotenv writes the variables of every credential under a prefix made
+// of its environment and nickname, or username, e.g. DEV_DB_PASSWORD.
+func exportDotenv(w io.Writer, creds auth.Credentials) error {
+	used := map[string]bool{}
+	for _, c := range creds {
+		name := c.Nickname
+		if name == "" {
+			name = c.Username
+		}
+		base := strings.Trim(dotenvInvalid.ReplaceAllString(strings.ToUpper(c.Environment+"_"+name), "_"), "_")
+		if base == "" || base[0] >= '0' && base[0] <= '9' {
+			base = "CRED_" + base
+		}
+		prefix := base
+		for n := 2; used[prefix]; n++ {
+			prefix = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[prefix] = true
+
+		vars := [][2]string{
+			{"ENVIRONMENT", c.Environment},
+			{"TYPE", c.Kind()},
+			{"NICKNAME", c.Nickname},
+			{"USERNAME", c.Username},
+			{"PASSWORD", c.Password},
+		}
+		if c.OTPSeed != "" {
+			vars = append(vars, [2]string{"OTP_SEED", c.OTPSeed})
+		}
+		for _, name := range slices.Sorted(maps.Keys(c.Fields)) {
+			vars = append(vars, [2]string{strings.ToUpper(name), c.Fields[name]})
+		}
+
+		for _, v := range vars {
+			if _, err := fmt.Fprintf(w, "%s_%s=%s\n", prefix, v[0], quoteDotenv(v[1])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// quoteDotenv single quotes a value, so shells and dotenv loaders take it
+// literally, unless it holds single quotes or control characters such as
+// line breaks, which need the escapes of a double quoted value.
+func quoteDotenv(value string) string {
+	if strings.ContainsFunc(value, func(r rune) bool { return r == '\'' || r < ' ' || r == 0x7f }) {
+		return strconv.Quote(value)
+	}
+	return "'" + value + "'"
+}
+
+// parseJSON reads a JSON export, the array of credentials 'dwing creds list
+// -o json --show-secrets' prints too.
+func parseJSON(r io.Reader) ([]Entry, error) {
+	var creds auth.Credentials
+	if err := json.NewDecoder(r).Decode(&creds); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(creds))
+	for _, c := range creds {
+		entries = append(entries, Entry{Source: c.Environment + "/" + c.Username, Credential: c})
+	}
+	return entries, nil
+}
+
+// parseExportCSV reads a CSV export. Columns that are not in exportColumns
+// set the type field of the same name.
+func parseExportCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"environment", "username"} {
+		if !slices.Contains(header, name) {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var c auth.Credential
+		for i, name := range header {
+			switch name {
+			case "id":
+				// The importing store assigns new IDs.
+			case "environment":
+				c.Environment = record[i]
+			case "type":
+				c.Type = record[i]
+			case "nickname":
+				c.Nickname = record[i]
+			default:
+				c.Set(name, record[i])
+			}
+		}
+		if c.Type == auth.TypePassword {
+			c.Type = ""
+		}
+
+		entries = append(entries, Entry{Source: c.Environment + "/" + c.Username, Credential: c})
+	}
+	return entries, nil
+}
@@ -0,0 +1,152 @@
+package transfer_test
+
+import (
+	"bytes"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/transfer"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exported = auth.Credentials{
+	{ID: "1", Environment: "dev", Username: "alice", Password: "it's a secret", Nickname: "admin", OTPSeed: "JBSWY3DPEHPK3PXP"},
+	{ID: "2", Environment: "dev", Type: auth.TypeDatabase, Username: "app", Password: "p$w", Fields: map[string]string{"host": "db.dev", "port": "5432"}},
+	{ID: "3", Environment: "prod", Type: auth.TypeSSHKey, Username: "deploy", Nickname: "admin",
+		Fields: map[string]string{"private_key": "-----BEGIN KEY-----\nabc\n-----END KEY-----"}},
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, format := range []string{transfer.FormatJSON, transfer.FormatCSV, transfer.FormatDotenv} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, transfer.Export(format, &buf, exported))
+
+			entries, err := transfer.Parse(format, &buf)
+			require.NoError(t, err)
+			require.Len(t, entries, len(exported))
+
+			for i, entry := range entries {
+				want := exported[i]
+				got := entry.Credential
+				got.ID = want.ID
+				assert.Equal(t, want, got)
+				assert.NoError(t, got.Validate())
+			}
+		})
+	}
+}
+
+func TestExportDotenv(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, transfer.Export(transfer.FormatDotenv, &buf, exported[:2]))
+
+	assert.Equal(t, heredoc.Doc(`
+		DEV_ADMIN_ENVIRONMENT='dev'
+		DEV_ADMIN_TYPE='password'
+		DEV_ADMIN_NICKNAME='admin'
+		DEV_ADMIN_USERNAME='alice'
+		DEV_ADMIN_PASSWORD="it's a secret"
+		DEV_ADMIN_OTP_SEED='JBSWY3DPEHPK3PXP'
+		DEV_APP_ENVIRONMENT='dev'
+		DEV_APP_TYPE='database'
+		DEV_APP_NICKNAME=''
+		DEV_APP_USERNAME='app'
+		DEV_APP_PASSWORD='p$w'
+		DEV_APP_HOST='db.dev'
+		DEV_APP_PORT='5432'
+	`), buf.String())
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, transfer.Export(transfer.FormatCSV, &buf, exported[:2]))
+
+	assert.Equal(t, heredoc.Doc(`
+		id,environment,type,username,password,nickname,otp_seed,host,port
+		1,dev,password,alice,it's a secret,admin,JBSWY3DPEHPK3PXP,,
+		2,dev,database,app,p$w,,,db.dev,5432
+	`), buf.String())
+}
+
+func TestBundle(t *testing.T) {
+	encrypt := func(t *testing.T, recipients ...age.Recipient) []byte {
+		var buf bytes.Buffer
+		w, err := transfer.Encrypt(&buf, recipients...)
+		require.NoError(t, err)
+		require.NoError(t, transfer.Export(transfer.FormatJSON, w, exported))
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	decrypt := func(t *testing.T, bundle []byte, identities ...age.Identity) (auth.Credentials, error) {
+		r, err := transfer.Decrypt(bytes.NewReader(bundle), identities...)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := transfer.Parse(transfer.FormatJSON, r)
+		require.NoError(t, err)
+
+		var creds auth.Credentials
+		for _, e := range entries {
+			creds = append(creds, e.Credential)
+		}
+		return creds, nil
+	}
+
+	t.Run("Passphrase", func(t *testing.T) {
+		recipient, err := age.NewScryptRecipient("correct horse")
+		require.NoError(t, err)
+		recipient.SetWorkFactor(10)
+		bundle := encrypt(t, recipient)
+
+		assert.True(t, bytes.HasPrefix(bundle, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n")))
+		assert.NotContains(t, string(bundle), "alice")
+
+		identity, err := age.NewScryptIdentity("correct horse")
+		require.NoError(t, err)
+		creds, err := decrypt(t, bundle, identity)
+		require.NoError(t, err)
+		assert.Equal(t, exported, creds)
+
+		wrong, err := age.NewScryptIdentity("battery staple")
+		require.NoError(t, err)
+		_, err = decrypt(t, bundle, wrong)
+		assert.ErrorIs(t, err, transfer.ErrBundleKey)
+	})
+
+	t.Run("Public keys", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		other, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		bundle := encrypt(t, identity.Recipient())
+
+		creds, err := decrypt(t, bundle, identity)
+		require.NoError(t, err)
+		assert.Equal(t, exported, creds)
+
+		_, err = decrypt(t, bundle, other)
+		assert.ErrorIs(t, err, transfer.ErrBundleKey)
+	})
+
+	t.Run("Binary age file", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		w, err := age.Encrypt(&buf, identity.Recipient())
+		require.NoError(t, err)
+		_, err = io.WriteString(w, `[{"environment":"dev","username":"alice","password":"pw"}]`)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		creds, err := decrypt(t, buf.Bytes(), identity)
+		require.NoError(t, err)
+		assert.Equal(t, auth.Credentials{{Environment: "dev", Username: "alice", Password: "pw"}}, creds)
+	})
+}
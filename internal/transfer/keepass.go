@@ -0,0 +1,81 @@
+package transfer
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+type keepassFile struct {
+	Meta struct {
+		RecycleBinUUID string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keepassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+// keepassEntry leaves out the History element, so previous versions of an
+// entry are not imported.
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// parseKeePass reads the KeePass 2 XML export, as written by KeePass and
+// KeePassXC. The group of an entry is its folder, except for the root group
+// which is named after the database. Entries in the recycle bin are left out.
+func parseKeePass(r io.Reader) ([]Entry, error) {
+	var file keepassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	var walk func(g keepassGroup, folder string)
+	walk = func(g keepassGroup, folder string) {
+		if file.Meta.RecycleBinUUID != "" && g.UUID == file.Meta.RecycleBinUUID {
+			return
+		}
+
+		for _, e := range g.Entries {
+			values := map[string]string{}
+			for _, s := range e.Strings {
+				values[strings.ToLower(s.Key)] = s.Value
+			}
+
+			entry := Entry{Source: sourceName(values["title"], values["url"]), Folder: folder}
+			entry.Credential.Environment = values["environment"]
+			entry.Credential.Nickname = Nickname(entry.Source)
+			entry.Credential.Username = values["username"]
+			entry.Credential.Password = values["password"]
+			// KeePassXC keeps an otpauth:// URI in "otp", KeePass a base32
+			// seed in "TimeOtp-Secret-Base32".
+			entry.Credential.OTPSeed = values["otp"]
+			if entry.Credential.OTPSeed == "" {
+				entry.Credential.OTPSeed = values["timeotp-secret-base32"]
+			}
+
+			entries = append(entries, entry)
+		}
+
+		for _, child := range g.Groups {
+			walk(child, child.Name)
+		}
+	}
+
+	for _, root := range file.Root.Groups {
+		walk(root, "")
+	}
+
+	return entries, nil
+}
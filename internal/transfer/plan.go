@@ -0,0 +1,187 @@
+package transfer
+
+import (
+	"fmt"
+	"jpellissari/dwing/internal/auth"
+	"slices"
+	"strings"
+)
+
+// What to do with an entry whose environment and username are already used,
+// by a stored credential or by an earlier entry of the file.
+const (
+	OnDuplicateSkip      = "skip"
+	OnDuplicateOverwrite = "overwrite"
+	OnDuplicateRename    = "rename"
+)
+
+var OnDuplicateStrategies = []string{OnDuplicateSkip, OnDuplicateOverwrite, OnDuplicateRename}
+
+const (
+	ActionAdd       = "add"
+	ActionOverwrite = "overwrite"
+	ActionRename    = "rename"
+	ActionSkip      = "skip"
+	ActionInvalid   = "invalid"
+)
+
+// Step is what importing an entry does to the credential store.
+type Step struct {
+	Action     string          `json:"action"`
+	Source     string          `json:"source"`
+	Credential auth.Credential `json:"credential"`
+	Reason     string          `json:"reason,omitempty"`
+}
+
+// Writes reports whether the step changes the store.
+func (s Step) Writes() bool {
+	return s.Action == ActionAdd || s.Action == ActionOverwrite || s.Action == ActionRename
+}
+
+type Steps []Step
+
+// Writes counts the steps that change the store.
+func (s Steps) Writes() int {
+	n := 0
+	for _, step := range s {
+		if step.Writes() {
+			n++
+		}
+	}
+	return n
+}
+
+func (s Steps) Redacted() Steps {
+	redacted := make(Steps, len(s))
+	for i, step := range s {
+		step.Credential = step.Credential.Redacted()
+		redacted[i] = step
+	}
+	return redacted
+}
+
+type PlanOptions struct {
+	// OnDuplicate is one of OnDuplicateStrategies, OnDuplicateSkip when
+	// empty.
+	OnDuplicate string
+	// Environment returns the environment an entry is imported into.
+	Environment func(Entry) (string, error)
+}
+
+// Plan decides what importing entries into a store holding existing does,
+// without changing anything. Entries that fail validation are planned as
+// ActionInvalid. Overwritten credentials keep their ID and nickname, renamed
+// ones get a " (2)" suffix on their username. Nicknames already in use get a
+// "-2" suffix.
+func Plan(entries []Entry, existing auth.Credentials, opts PlanOptions) (Steps, error) {
+	strategy := opts.OnDuplicate
+	if strategy == "" {
+		strategy = OnDuplicateSkip
+	}
+	if !slices.Contains(OnDuplicateStrategies, strategy) {
+		return nil, fmt.Errorf("unknown duplicate strategy %q, expected one of: %s", strategy, strings.Join(OnDuplicateStrategies, ", "))
+	}
+
+	// owners maps the environment and username of a credential to the step
+	// that writes it, or to -1 for a stored credential left untouched.
+	owners := map[string]int{}
+	stored := map[string]auth.Credential{}
+	nicknames := map[string]bool{}
+	for _, c := range existing {
+		owners[usernameKey(c)] = -1
+		stored[usernameKey(c)] = c
+		if c.Nickname != "" {
+			nicknames[nicknameKey(c)] = true
+		}
+	}
+
+	steps := make(Steps, 0, len(entries))
+	for _, entry := range entries {
+		step := Step{Action: ActionAdd, Source: entry.Source, Credential: entry.Credential}
+		step.Credential.ID = ""
+
+		env, err := opts.Environment(entry)
+		if err != nil {
+			steps = append(steps, invalid(step, err))
+			continue
+		}
+		step.Credential.Environment = env
+
+		if err := step.Credential.Validate(); err != nil {
+			steps = append(steps, invalid(step, err))
+			continue
+		}
+
+		key := usernameKey(step.Credential)
+		owner, duplicate := owners[key]
+		if duplicate {
+			switch strategy {
+			case OnDuplicateSkip:
+				step.Action = ActionSkip
+				step.Reason = fmt.Sprintf("%s already exists in %s", step.Credential.Username, env)
+				steps = append(steps, step)
+				continue
+
+			case OnDuplicateOverwrite:
+				step.Action = ActionOverwrite
+				if owner < 0 {
+					step.Credential.ID = stored[key].ID
+					step.Credential.Nickname = stored[key].Nickname
+				} else {
+					// A later entry of the file wins over an earlier one.
+					earlier := steps[owner]
+					step.Action = earlier.Action
+					step.Credential.ID = earlier.Credential.ID
+					step.Credential.Nickname = earlier.Credential.Nickname
+					steps[owner].Action = ActionSkip
+					steps[owner].Reason = "replaced by a later entry"
+				}
+				owners[key] = len(steps)
+				steps = append(steps, step)
+				continue
+
+			case OnDuplicateRename:
+				username := step.Credential.Username
+				for n := 2; duplicate; n++ {
+					step.Credential.Username = fmt.Sprintf("%s (%d)", username, n)
+					_, duplicate = owners[usernameKey(step.Credential)]
+				}
+				if err := step.Credential.Validate(); err != nil {
+					steps = append(steps, invalid(step, err))
+					continue
+				}
+				step.Action = ActionRename
+				step.Reason = fmt.Sprintf("%s already exists in %s", username, env)
+				key = usernameKey(step.Credential)
+			}
+		}
+
+		if nickname := step.Credential.Nickname; nickname != "" {
+			for n := 2; nicknames[nicknameKey(step.Credential)]; n++ {
+				step.Credential.Nickname = fmt.Sprintf("%s-%d", nickname, n)
+			}
+			nicknames[nicknameKey(step.Credential)] = true
+		}
+
+		owners[key] = len(steps)
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func invalid(step Step, err error) Step {
+	step.Action = ActionInvalid
+	step.Reason = err.Error()
+	return step
+}
+
+// usernameKey and nicknameKey fold the case of the environment like
+// auth.SameEnvironment does.
+func usernameKey(c auth.Credential) string {
+	return strings.ToLower(c.Environment) + "\x00" + c.Username
+}
+
+func nicknameKey(c auth.Credential) string {
+	return strings.ToLower(c.Environment) + "\x00" + c.Nickname
+}
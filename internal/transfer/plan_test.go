@@ -0,0 +1,122 @@
+package transfer_test
+
+import (
+	"errors"
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/transfer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func folderEnvironment(e transfer.Entry) (string, error) {
+	if e.Folder == "" {
+		return "", errors.New("no environment")
+	}
+	return e.Folder, nil
+}
+
+func entry(source, env, username string) transfer.Entry {
+	return transfer.Entry{
+		Source:     source,
+		Folder:     env,
+		Credential: auth.Credential{Username: username, Password: "pw-" + source, Nickname: transfer.Nickname(source)},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	existing := auth.Credentials{
+		{ID: "id-alice", Environment: "dev", Username: "alice", Nickname: "mine"},
+		{ID: "id-bob", Environment: "dev", Username: "bob", Nickname: "web"},
+	}
+	entries := []transfer.Entry{
+		entry("alice", "dev", "alice"),
+		entry("web", "dev", "carol"),
+		entry("carol again", "dev", "carol"),
+		entry("no env", "", "dave"),
+		entry("no user", "dev", ""),
+	}
+
+	type row struct {
+		action, username, nickname, id string
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		want     []row
+	}{
+		{
+			name:     "Skip duplicates",
+			strategy: transfer.OnDuplicateSkip,
+			want: []row{
+				{transfer.ActionSkip, "alice", "alice", ""},
+				{transfer.ActionAdd, "carol", "web-2", ""},
+				{transfer.ActionSkip, "carol", "carol-again", ""},
+				{transfer.ActionInvalid, "dave", "no-env", ""},
+				{transfer.ActionInvalid, "", "no-user", ""},
+			},
+		},
+		{
+			name:     "Overwrite duplicates",
+			strategy: transfer.OnDuplicateOverwrite,
+			want: []row{
+				{transfer.ActionOverwrite, "alice", "mine", "id-alice"},
+				{transfer.ActionSkip, "carol", "web-2", ""},
+				{transfer.ActionAdd, "carol", "web-2", ""},
+				{transfer.ActionInvalid, "dave", "no-env", ""},
+				{transfer.ActionInvalid, "", "no-user", ""},
+			},
+		},
+		{
+			name:     "Rename duplicates",
+			strategy: transfer.OnDuplicateRename,
+			want: []row{
+				{transfer.ActionRename, "alice (2)", "alice", ""},
+				{transfer.ActionAdd, "carol", "web-2", ""},
+				{transfer.ActionRename, "carol (2)", "carol-again", ""},
+				{transfer.ActionInvalid, "dave", "no-env", ""},
+				{transfer.ActionInvalid, "", "no-user", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := transfer.Plan(entries, existing, transfer.PlanOptions{
+				OnDuplicate: tt.strategy,
+				Environment: folderEnvironment,
+			})
+			require.NoError(t, err)
+
+			got := make([]row, len(steps))
+			for i, s := range steps {
+				got[i] = row{s.Action, s.Credential.Username, s.Credential.Nickname, s.Credential.ID}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlanReasons(t *testing.T) {
+	steps, err := transfer.Plan([]transfer.Entry{
+		entry("a", "dev", "alice"),
+		entry("b", "", "bob"),
+		entry("c", "dev", "alice"),
+	}, nil, transfer.PlanOptions{Environment: folderEnvironment})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, steps.Writes())
+	assert.Equal(t, "no environment", steps[1].Reason)
+	assert.Equal(t, "alice already exists in dev", steps[2].Reason)
+	assert.Equal(t, "dev", steps[2].Credential.Environment)
+	assert.Equal(t, "********", steps.Redacted()[0].Credential.Password)
+	assert.Equal(t, "pw-a", steps[0].Credential.Password)
+}
+
+func TestPlanUnknownStrategy(t *testing.T) {
+	_, err := transfer.Plan(nil, nil, transfer.PlanOptions{OnDuplicate: "merge", Environment: folderEnvironment})
+
+	assert.ErrorContains(t, err, "unknown duplicate strategy")
+}
@@ -0,0 +1,96 @@
+// Package transfer moves credentials in and out of a credential store: it
+// reads the export files of other password managers, plans how they are
+// merged into a store, and writes exports and encrypted bundles.
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jpellissari/dwing/internal/auth"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	FormatJSON      = "json"
+	FormatCSV       = "csv"
+	FormatBundle    = "bundle"
+	FormatBitwarden = "bitwarden"
+	Format1Password = "1password"
+	FormatKeePass   = "keepass"
+	FormatChrome    = "chrome"
+	FormatFirefox   = "firefox"
+	FormatDotenv    = "dotenv"
+)
+
+// Formats lists the formats Parse reads. FormatJSON and FormatCSV are the
+// files written by Export, an encrypted bundle is read as FormatJSON once
+// Decrypt opened it.
+var Formats = []string{FormatJSON, FormatCSV, FormatBitwarden, Format1Password, FormatKeePass, FormatChrome, FormatFirefox, FormatDotenv}
+
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// Entry is a credential read from an export file.
+type Entry struct {
+	// Source names the entry in the file, e.g. the title of a Bitwarden
+	// item, so it can be recognized in reports.
+	Source string `json:"source"`
+	// Folder is the folder or group the entry was filed under, which is
+	// used as its environment when the file has no environment column.
+	Folder string `json:"folder,omitempty"`
+	// Credential holds the imported values. Its Environment is only set
+	// when the file has an environment column or field.
+	Credential auth.Credential `json:"credential"`
+}
+
+// Parse reads the entries of a file in the given format. Items that are not
+// logins, such as Bitwarden cards and notes, are left out.
+func Parse(format string, r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var err error
+
+	switch format {
+	case FormatJSON:
+		entries, err = parseJSON(r)
+	case FormatCSV:
+		entries, err = parseExportCSV(r)
+	case FormatBitwarden:
+		entries, err = parseBitwarden(r)
+	case Format1Password, FormatChrome, FormatFirefox:
+		entries, err = parseCSV(format, r)
+	case FormatKeePass:
+		entries, err = parseKeePass(r)
+	case FormatDotenv:
+		entries, err = parseDotenv(r)
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %w", format, err)
+	}
+
+	return entries, nil
+}
+
+var nicknameInvalid = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// Nickname turns the name of an entry into a nickname, e.g. "GitHub (work)"
+// becomes "github-work".
+func Nickname(name string) string {
+	name = nicknameInvalid.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name, "-.")
+}
+
+// sourceName names an entry by its title, or by the host of its URL when it
+// has none, as in Firefox exports.
+func sourceName(title, rawURL string) string {
+	if title = strings.TrimSpace(title); title != "" {
+		return title
+	}
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return rawURL
+}
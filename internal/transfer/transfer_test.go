@@ -0,0 +1,200 @@
+package transfer_test
+
+import (
+	"jpellissari/dwing/internal/auth"
+	"jpellissari/dwing/internal/transfer"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []transfer.Entry
+	}{
+		{
+			name:   "Bitwarden logins with folders",
+			format: transfer.FormatBitwarden,
+			input: heredoc.Doc(`
+				{
+				  "encrypted": false,
+				  "folders": [{"id": "f1", "name": "dev"}],
+				  "items": [
+				    {"type": 1, "name": "GitHub (work)", "folderId": "f1",
+				     "login": {"username": "alice", "password": "s3cret", "totp": "JBSWY3DPEHPK3PXP",
+				               "uris": [{"uri": "https://github.com/login"}]}},
+				    {"type": 1, "name": "", "folderId": null,
+				     "login": {"username": "bob", "password": "hunter2", "uris": [{"uri": "https://db.example.com"}]},
+				     "fields": [{"name": "Environment", "value": "prod", "type": 0}]},
+				    {"type": 2, "name": "Secure note"}
+				  ]
+				}
+			`),
+			want: []transfer.Entry{
+				{
+					Source: "GitHub (work)", Folder: "dev",
+					Credential: auth.Credential{Username: "alice", Password: "s3cret", OTPSeed: "JBSWY3DPEHPK3PXP", Nickname: "github-work"},
+				},
+				{
+					Source:     "db.example.com",
+					Credential: auth.Credential{Environment: "prod", Username: "bob", Password: "hunter2", Nickname: "db.example.com"},
+				},
+			},
+		},
+		{
+			name:   "1Password CSV",
+			format: transfer.Format1Password,
+			input: "\ufeffTitle,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes\n" +
+				"Staging API,https://api.staging,svc, pass with spaces ,,false,false,,\n",
+			want: []transfer.Entry{
+				{
+					Source:     "Staging API",
+					Credential: auth.Credential{Username: "svc", Password: " pass with spaces ", Nickname: "staging-api"},
+				},
+			},
+		},
+		{
+			name:   "Chrome CSV with environment column",
+			format: transfer.FormatChrome,
+			input:  "name,url,username,password,note,environment\nJenkins,https://ci.local,admin,pw,,qa\n",
+			want: []transfer.Entry{
+				{
+					Source:     "Jenkins",
+					Credential: auth.Credential{Environment: "qa", Username: "admin", Password: "pw", Nickname: "jenkins"},
+				},
+			},
+		},
+		{
+			name:   "Firefox CSV named after the host",
+			format: transfer.FormatFirefox,
+			input: heredoc.Doc(`
+				"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"
+				"https://grafana.dev.local:3000","viewer","pw","","https://grafana.dev.local:3000","{1}","1","1","1"
+			`),
+			want: []transfer.Entry{
+				{
+					Source:     "grafana.dev.local",
+					Credential: auth.Credential{Username: "viewer", Password: "pw", Nickname: "grafana.dev.local"},
+				},
+			},
+		},
+		{
+			name:   "KeePass XML groups without recycle bin or history",
+			format: transfer.FormatKeePass,
+			input: heredoc.Doc(`
+				<KeePassFile>
+				  <Meta><RecycleBinUUID>bin</RecycleBinUUID></Meta>
+				  <Root>
+				    <Group>
+				      <UUID>root</UUID><Name>Database</Name>
+				      <Entry>
+				        <String><Key>Title</Key><Value>Root entry</Value></String>
+				        <String><Key>UserName</Key><Value>carol</Value></String>
+				        <String><Key>Password</Key><Value ProtectInMemory="True">pw1</Value></String>
+				      </Entry>
+				      <Group>
+				        <UUID>g1</UUID><Name>staging</Name>
+				        <Entry>
+				          <String><Key>Title</Key><Value>Vault</Value></String>
+				          <String><Key>UserName</Key><Value>dave</Value></String>
+				          <String><Key>Password</Key><Value>pw2</Value></String>
+				          <String><Key>otp</Key><Value>otpauth://totp/x?secret=JBSWY3DPEHPK3PXP</Value></String>
+				          <History>
+				            <Entry>
+				              <String><Key>Title</Key><Value>Vault</Value></String>
+				              <String><Key>Password</Key><Value>old</Value></String>
+				            </Entry>
+				          </History>
+				        </Entry>
+				      </Group>
+				      <Group>
+				        <UUID>bin</UUID><Name>Recycle Bin</Name>
+				        <Entry><String><Key>Title</Key><Value>Deleted</Value></String></Entry>
+				      </Group>
+				    </Group>
+				  </Root>
+				</KeePassFile>
+			`),
+			want: []transfer.Entry{
+				{
+					Source:     "Root entry",
+					Credential: auth.Credential{Username: "carol", Password: "pw1", Nickname: "root-entry"},
+				},
+				{
+					Source: "Vault", Folder: "staging",
+					Credential: auth.Credential{Username: "dave", Password: "pw2", OTPSeed: "otpauth://totp/x?secret=JBSWY3DPEHPK3PXP", Nickname: "vault"},
+				},
+			},
+		},
+		{
+			name:   "dotenv grouped by prefix",
+			format: transfer.FormatDotenv,
+			input: heredoc.Doc(`
+				# database
+				export DB_USER=app
+				DB_PASSWORD="p@ss\nword"
+				DB_HOST=db.local # primary
+				DB_TYPE=database
+				DB_URL=postgres://ignored
+				DB_ADMIN_USERNAME=root
+				DB_ADMIN_PASSWORD='lit$ral'
+				DB_ADMIN_ENVIRONMENT=prod
+				LOG_LEVEL=debug
+			`),
+			want: []transfer.Entry{
+				{
+					Source: "DB",
+					Credential: auth.Credential{
+						Type: auth.TypeDatabase, Username: "app", Password: "p@ss\nword", Nickname: "db",
+						Fields: map[string]string{"host": "db.local"},
+					},
+				},
+				{
+					Source:     "DB_ADMIN",
+					Credential: auth.Credential{Environment: "prod", Username: "root", Password: "lit$ral", Nickname: "db-admin"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := transfer.Parse(tt.format, strings.NewReader(tt.input))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, entries)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		wantErr string
+	}{
+		{name: "Unknown format", format: "lastpass", input: "", wantErr: "unknown import format"},
+		{name: "Encrypted Bitwarden export", format: transfer.FormatBitwarden, input: `{"encrypted": true}`, wantErr: "encrypted exports are not supported"},
+		{name: "CSV of another tool", format: transfer.FormatChrome, input: "url,username,password\n", wantErr: `missing "name" column`},
+		{name: "Invalid XML", format: transfer.FormatKeePass, input: "<KeePassFile>", wantErr: "failed to read keepass file"},
+		{name: "dotenv line without value", format: transfer.FormatDotenv, input: "DB_USER\n", wantErr: "line 1: expected KEY=value"},
+		{name: "dotenv unterminated quote", format: transfer.FormatDotenv, input: "A=1\nDB_PASSWORD=\"secret\n", wantErr: "line 2: invalid quoted value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := transfer.Parse(tt.format, strings.NewReader(tt.input))
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.NotContains(t, err.Error(), "secret")
+		})
+	}
+}
@@ -1,12 +1,21 @@
 package main
 
-import "jpellissari/dwing/cmd"
+import (
+	"errors"
+	"jpellissari/dwing/cmd"
+	"jpellissari/dwing/internal/cmdutil"
+	"os"
+)
 
 func main() {
 	cmd := cmd.NewCmdRoot()
 
 	err := cmd.Execute()
 	if err != nil {
+		var exitErr *cmdutil.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		panic(err)
 	}
 }